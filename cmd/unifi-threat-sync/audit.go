@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/0x4272616E646F6E/unifi-threat-sync/internal/audit"
+	"github.com/0x4272616E646F6E/unifi-threat-sync/internal/config"
+)
+
+// runAudit implements the "audit" subcommand, printing recent audit records
+func runAudit(args []string) int {
+	fs := flag.NewFlagSet("audit", flag.ExitOnError)
+	configPath := fs.String("config", "/config/config.yaml", "Path to configuration file")
+	limit := fs.Int("n", 20, "Number of most recent records to show (0 = all)")
+	asJSON := fs.Bool("json", false, "Print records as JSON Lines")
+	verbose := fs.Bool("v", false, "Print added and removed prefixes")
+	fs.Parse(args)
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading configuration: %v\n", err)
+		return 1
+	}
+
+	records, err := audit.Read(cfg.Audit.Path, *limit)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading audit log: %v\n", err)
+		return 1
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	for _, rec := range records {
+		if *asJSON {
+			enc.Encode(rec)
+			continue
+		}
+
+		status := "ok"
+		if rec.Error != "" {
+			status = "FAILED: " + rec.Error
+		}
+		fmt.Printf("%s  %-6s  %s/%s  +%d -%d  feeds=[%s]  config=%.12s  %s\n",
+			rec.Timestamp.Format(time.RFC3339), rec.Action, rec.Site, rec.Group,
+			rec.AddedCount, rec.RemovedCount, strings.Join(rec.Feeds, ", "), rec.ConfigHash, status)
+		if *verbose {
+			for _, p := range rec.Added {
+				fmt.Printf("    + %s\n", p)
+			}
+			for _, p := range rec.Removed {
+				fmt.Printf("    - %s\n", p)
+			}
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/0x4272616E646F6E/unifi-threat-sync/internal/config"
+	"github.com/0x4272616E646F6E/unifi-threat-sync/internal/sync"
+)
+
+// runLookup implements the "lookup" subcommand. It asks the running service
+// why an IP is blocked, since the published lists only live in its memory.
+func runLookup(args []string) int {
+	fs := flag.NewFlagSet("lookup", flag.ExitOnError)
+	configPath := fs.String("config", "/config/config.yaml", "Path to configuration file")
+	server := fs.String("server", "", "Base URL of the running service (default: http://localhost:<health.port>)")
+	asJSON := fs.Bool("json", false, "Print the raw JSON response")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s lookup [flags] <ip>\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if _, err := netip.ParseAddr(fs.Arg(0)); fs.NArg() != 1 || err != nil {
+		fs.Usage()
+		return 2
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading configuration: %v\n", err)
+		return 1
+	}
+	if *server == "" {
+		*server = fmt.Sprintf("http://localhost:%d", cfg.Health.Port)
+	}
+
+	req, err := http.NewRequest("GET", strings.TrimRight(*server, "/")+"/api/lookup?ip="+url.QueryEscape(fs.Arg(0)), nil)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating request: %v\n", err)
+		return 1
+	}
+	req.Header.Set("Authorization", "Bearer "+cfg.API.Token)
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Lookup request failed: %v\n", err)
+		return 1
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		fmt.Fprintf(os.Stderr, "Lookup failed with status %d: %s\n", resp.StatusCode, strings.TrimSpace(string(body)))
+		return 1
+	}
+	if *asJSON {
+		fmt.Println(string(body))
+		return 0
+	}
+
+	var result sync.LookupResult
+	if err := json.Unmarshal(body, &result); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid lookup response: %v\n", err)
+		return 1
+	}
+
+	if !result.Blocked {
+		fmt.Printf("%s is not blocked\n", result.IP)
+	} else {
+		fmt.Printf("%s is blocked by %d prefix(es):\n", result.IP, len(result.Matches))
+		for _, m := range result.Matches {
+			fmt.Printf("  %-20s group=%s (profile %s)\n", m.CIDR, m.Group, m.Profile)
+			fmt.Printf("    feeds: %s\n", strings.Join(m.Sources, ", "))
+			if !m.FirstSeen.IsZero() {
+				fmt.Printf("    first seen: %s, last seen: %s\n",
+					m.FirstSeen.Format(time.RFC3339), m.LastSeen.Format(time.RFC3339))
+			}
+		}
+	}
+	if len(result.Allowlist) > 0 {
+		fmt.Printf("Allowlist entries covering %s: %s\n", result.IP, strings.Join(result.Allowlist, ", "))
+	}
+
+	return 0
+}
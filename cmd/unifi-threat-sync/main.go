@@ -2,19 +2,29 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"net/netip"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"github.com/0x4272616E646F6E/unifi-threat-sync/internal/audit"
 	"github.com/0x4272616E646F6E/unifi-threat-sync/internal/config"
 	"github.com/0x4272616E646F6E/unifi-threat-sync/internal/http"
+	"github.com/0x4272616E646F6E/unifi-threat-sync/internal/leader"
+	"github.com/0x4272616E646F6E/unifi-threat-sync/internal/manual"
+	"github.com/0x4272616E646F6E/unifi-threat-sync/internal/notify"
+	"github.com/0x4272616E646F6E/unifi-threat-sync/internal/scheduler"
 	"github.com/0x4272616E646F6E/unifi-threat-sync/internal/sync"
 	"github.com/0x4272616E646F6E/unifi-threat-sync/internal/unifi"
 )
 
+// errStandby rejects sync requests made to a replica without leadership
+var errStandby = errors.New("this instance is on standby, send sync requests to the leader")
+
 var (
 	Version   = "dev"
 	Commit    = "unknown"
@@ -22,9 +32,20 @@ var (
 )
 
 func main() {
+	// Subcommands
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "audit":
+			os.Exit(runAudit(os.Args[2:]))
+		case "lookup":
+			os.Exit(runLookup(os.Args[2:]))
+		}
+	}
+
 	// Command-line flags
 	configPath := flag.String("config", "/config/config.yaml", "Path to configuration file")
 	versionFlag := flag.Bool("version", false, "Print version information")
+	onceFlag := flag.Bool("once", false, "Run a single sync cycle and exit with a code describing the result")
 	flag.Parse()
 
 	// Print version and exit
@@ -50,8 +71,20 @@ func main() {
 
 	fmt.Printf("UniFi Threat Sync %s starting...\n", Version)
 	fmt.Printf("UniFi Controller: %s\n", cfg.UniFi.URL)
-	fmt.Printf("Sync Interval: %s\n", cfg.Sync.Interval)
+	if cfg.Sync.Cron != "" {
+		fmt.Printf("Sync Schedule: %s\n", cfg.Sync.Cron)
+	} else {
+		fmt.Printf("Sync Interval: %s\n", cfg.Sync.Interval)
+	}
 	fmt.Printf("Enabled Feeds: %d\n", cfg.Feeds.EnabledCount())
+	fmt.Printf("Fetch Concurrency: %d\n", cfg.Sync.Concurrency)
+
+	// Create sync scheduler
+	sched, err := scheduler.New(cfg.Sync)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid configuration: %v\n", err)
+		os.Exit(1)
+	}
 
 	// Create UniFi client
 	unifiClient, err := unifi.NewClient(cfg.UniFi)
@@ -65,6 +98,9 @@ func main() {
 	if err := unifiClient.Login(ctx); err != nil {
 		cancel()
 		fmt.Fprintf(os.Stderr, "Failed to connect to UniFi controller: %v\n", err)
+		if *onceFlag {
+			os.Exit(exitController)
+		}
 		os.Exit(1)
 	}
 	cancel()
@@ -72,11 +108,105 @@ func main() {
 
 	// Create sync service
 	syncer := sync.New(cfg, unifiClient)
+	syncer.SetPushGate(sched)
 
-	// Start health check server if enabled
+	// requestRun records an on-demand run and wakes the sync loop to start
+	// it. A standby never runs syncs, so requests to it are rejected.
+	var elector *leader.Leader
 	var healthServer *http.HealthServer
+	requestRun := func(trigger string) (sync.RunInfo, error) {
+		if elector != nil && !elector.IsLeader() {
+			return sync.RunInfo{}, errStandby
+		}
+		run := syncer.Request(trigger)
+		sched.Trigger()
+		return run, nil
+	}
+
+	// Set up notifications if any targets are configured
+	var notifier *notify.Notifier
+	if len(cfg.Notify.Targets) > 0 {
+		notifier, err = notify.New(cfg.Notify)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid configuration: %v\n", err)
+			os.Exit(1)
+		}
+		syncer.SetNotifier(notifier)
+	}
+
+	// Open audit log if enabled
+	var auditLog *audit.Logger
+	if cfg.Audit.Enabled {
+		auditLog = audit.New(cfg.Audit.Path, cfg.Audit.MaxSize(), cfg.Audit.MaxAge, cfg.Audit.Backups())
+		defer auditLog.Close()
+		syncer.SetAuditLog(auditLog)
+	}
+
+	// Load manually blocked entries if enabled
+	var manualStore *manual.Store
+	if cfg.Manual.Enabled {
+		manualStore, err = manual.NewStore(cfg.Manual.Path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to load manual entries: %v\n", err)
+			os.Exit(1)
+		}
+		manualStore.SetPolicy(manual.PolicyFrom(cfg.Filter))
+		syncer.SetManualSource(manualStore)
+	}
+
+	// Run a single cycle and exit; deferred calls don't run on os.Exit
+	if *onceFlag {
+		code := runOnce(syncer)
+		if notifier != nil {
+			notifier.Wait()
+		}
+		if auditLog != nil {
+			auditLog.Close()
+		}
+		os.Exit(code)
+	}
+
+	// With leader election only the leader syncs; it starts a run as soon as
+	// it takes over. The elector exists before the health server and signal
+	// handlers start, so an instance is a standby until it wins the lease.
+	if cfg.Leader.Enabled {
+		lease := leader.NewFileLease(cfg.Leader.Path, cfg.Leader.ID, cfg.Leader.LeaseDuration)
+		elector = leader.New(lease, cfg.Leader.RenewInterval, func(isLeader bool) {
+			if healthServer != nil {
+				healthServer.SetLeader(isLeader)
+			}
+			if isLeader {
+				requestRun("leader")
+			}
+		})
+	}
+
+	// Start health check server if enabled
 	if cfg.Health.Enabled {
 		healthServer = http.NewHealthServer(cfg.Health.Port, Version)
+		if cfg.API.Token != "" {
+			healthServer.EnableAPI(cfg.API.Token, func() { requestRun("api") })
+			healthServer.EnableSync(func() (string, interface{}, error) {
+				run, err := requestRun("api")
+				return run.ID, run, err
+			}, func(id string) (interface{}, bool) {
+				return syncer.RunInfo(id)
+			}, func() interface{} {
+				return syncer.Runs()
+			})
+			if manualStore != nil {
+				healthServer.EnableManual(manualStore)
+			}
+			if cfg.Audit.Enabled {
+				healthServer.EnableAudit(cfg.Audit.Path)
+			}
+			healthServer.EnableLookup(func(addr netip.Addr) interface{} {
+				return syncer.Lookup(addr)
+			})
+		}
+		if elector != nil {
+			healthServer.SetLeader(false)
+		}
 		if err := healthServer.Start(); err != nil {
 			fmt.Fprintf(os.Stderr, "Failed to start health server: %v\n", err)
 			os.Exit(1)
@@ -89,45 +219,120 @@ func main() {
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
-	// Run initial sync
-	fmt.Println("Running initial sync...")
-	if err := syncer.Run(ctx); err != nil {
-		fmt.Fprintf(os.Stderr, "Initial sync failed: %v\n", err)
-		if healthServer != nil {
-			healthServer.RecordError()
-		}
-		// Don't exit, continue with periodic sync
+	// Sync right away on SIGUSR1
+	usr := make(chan os.Signal, 1)
+	if len(syncSignals) > 0 {
+		signal.Notify(usr, syncSignals...)
 	}
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-usr:
+				run, err := requestRun("signal")
+				if err != nil {
+					fmt.Printf("Sync requested by signal ignored: %v\n", err)
+					continue
+				}
+				fmt.Printf("Sync requested by signal (run %s)\n", run.ID)
+			}
+		}
+	}()
 
-	// Start periodic sync
-	ticker := time.NewTicker(cfg.Sync.Interval)
-	defer ticker.Stop()
+	// Reload the configuration on SIGHUP and, if enabled, on file changes
+	// A standby still wakes the loop so the new configuration is applied
+	reload := &reloader{path: *configPath, startup: cfg, health: healthServer, manual: manualStore, trigger: func() {
+		if _, err := requestRun("reload"); err != nil {
+			sched.Trigger()
+		}
+	}}
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hup:
+				reload.reload("SIGHUP")
+			}
+		}
+	}()
+	if cfg.Reload.Watch {
+		fmt.Printf("Watching %s for changes (every %s)\n", *configPath, cfg.Reload.Interval)
+		go config.Watch(ctx, *configPath, cfg.Hash(), cfg.Reload.Interval, func() {
+			reload.reload("file changed")
+		})
+	}
 
-	fmt.Printf("Sync loop started (interval: %s)\n", cfg.Sync.Interval)
+	// Campaign for leadership, or sync right away without leader election
+	leaderDone := make(chan struct{})
+	if elector != nil {
+		fmt.Printf("Leader election enabled (id: %s, lease: %s)\n", cfg.Leader.ID, cfg.Leader.Path)
+		elector.Campaign(ctx)
+		go func() {
+			elector.Run(ctx)
+			close(leaderDone)
+		}()
+	} else {
+		close(leaderDone)
 
-	for {
-		select {
-		case <-ctx.Done():
-			fmt.Println("\nShutdown signal received, cleaning up...")
-			
-			// Shutdown health server
+		// Run initial sync
+		fmt.Println("Running initial sync...")
+		if err := syncer.Run(ctx); err != nil {
+			fmt.Fprintf(os.Stderr, "Initial sync failed: %v\n", err)
 			if healthServer != nil {
-				shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-				defer cancel()
-				if err := healthServer.Stop(shutdownCtx); err != nil {
-					fmt.Fprintf(os.Stderr, "Error stopping health server: %v\n", err)
-				}
+				healthServer.RecordError()
 			}
-			
+			// Don't exit, continue with periodic sync
+		}
+	}
+
+	// Start periodic sync
+	fmt.Printf("Sync loop started (schedule: %s)\n", sched)
+
+	sched.Run(ctx, func(ctx context.Context) {
+		reload.apply(sched, syncer)
+		if elector != nil && !elector.IsLeader() {
+			// A run requested before leadership was lost can no longer start
+			syncer.DiscardPending(errStandby)
+			fmt.Println("Standby, skipping sync")
 			return
-		case <-ticker.C:
-			fmt.Printf("\n[%s] Starting scheduled sync...\n", time.Now().Format(time.RFC3339))
-			if err := syncer.Run(context.Background()); err != nil {
-				fmt.Fprintf(os.Stderr, "Sync failed: %v\n", err)
-				if healthServer != nil {
-					healthServer.RecordError()
-				}
+		}
+		// Stop writing to the controller as soon as leadership is lost, so
+		// the old and the new leader never push at the same time
+		runCtx := ctx
+		if elector != nil {
+			var cancel context.CancelFunc
+			runCtx, cancel = elector.Context(ctx)
+			defer cancel()
+		}
+		fmt.Printf("\n[%s] Starting scheduled sync...\n", time.Now().Format(time.RFC3339))
+		if err := syncer.Run(runCtx); err != nil {
+			fmt.Fprintf(os.Stderr, "Sync failed: %v\n", err)
+			if healthServer != nil {
+				healthServer.RecordError()
 			}
 		}
+	})
+
+	fmt.Println("\nShutdown signal received, cleaning up...")
+
+	// Give up leadership so a standby takes over right away
+	<-leaderDone
+
+	// Let pending notifications finish
+	if notifier != nil {
+		notifier.Wait()
+	}
+
+	// Shutdown health server
+	if healthServer != nil {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := healthServer.Stop(shutdownCtx); err != nil {
+			fmt.Fprintf(os.Stderr, "Error stopping health server: %v\n", err)
+		}
 	}
 }
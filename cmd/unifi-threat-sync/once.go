@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/0x4272616E646F6E/unifi-threat-sync/internal/sync"
+)
+
+// Exit codes of -once mode
+const (
+	exitSuccess      = 0 // Changes pushed
+	exitError        = 1 // Invalid configuration or other failure
+	exitNoChanges    = 3 // Nothing needed pushing
+	exitPartialFeeds = 4 // Synced, but one or more feeds failed and were left out
+	exitSafety       = 5 // Push aborted by a safety limit
+	exitController   = 6 // Controller login or update failed
+	exitBlackout     = 7 // Changes held back by a blackout window
+)
+
+// runOnce runs a single sync cycle and returns the exit code describing it
+func runOnce(syncer *sync.Syncer) int {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	requested := syncer.Request("once")
+	err := syncer.Run(ctx)
+	run, _ := syncer.RunInfo(requested.ID)
+
+	code := exitCode(run, err)
+	switch code {
+	case exitSuccess:
+		fmt.Println("Sync completed, changes pushed")
+	case exitNoChanges:
+		fmt.Println("Sync completed, no changes")
+	case exitPartialFeeds:
+		fmt.Fprintf(os.Stderr, "Sync completed with failed feeds: %v\n", run.FailedFeeds)
+	case exitBlackout:
+		fmt.Println("Sync completed, changes held back by a blackout window")
+	default:
+		fmt.Fprintf(os.Stderr, "Sync failed: %v\n", err)
+	}
+	return code
+}
+
+// exitCode maps the outcome of a run to an exit code
+func exitCode(run sync.RunInfo, err error) int {
+	switch {
+	case errors.Is(err, sync.ErrController):
+		return exitController
+	case errors.Is(err, sync.ErrSafetyLimit):
+		return exitSafety
+	case err != nil:
+		return exitError
+	case run.Held:
+		return exitBlackout
+	case len(run.FailedFeeds) > 0:
+		return exitPartialFeeds
+	case !run.Changed:
+		return exitNoChanges
+	}
+	return exitSuccess
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/0x4272616E646F6E/unifi-threat-sync/internal/sync"
+)
+
+func TestExitCode(t *testing.T) {
+	failed := []string{"blocklist-de"}
+	tests := []struct {
+		name string
+		run  sync.RunInfo
+		err  error
+		want int
+	}{
+		{"changes pushed", sync.RunInfo{Changed: true}, nil, exitSuccess},
+		{"nothing to push", sync.RunInfo{}, nil, exitNoChanges},
+		{"failed feeds", sync.RunInfo{Changed: true, FailedFeeds: failed}, nil, exitPartialFeeds},
+		{"failed feeds without changes", sync.RunInfo{FailedFeeds: failed}, nil, exitPartialFeeds},
+		{"held by blackout", sync.RunInfo{Held: true}, nil, exitBlackout},
+		{"held beats failed feeds", sync.RunInfo{Held: true, FailedFeeds: failed}, nil, exitBlackout},
+		{"safety limit", sync.RunInfo{}, fmt.Errorf("profile default: %w", sync.ErrSafetyLimit), exitSafety},
+		{"controller", sync.RunInfo{Changed: true}, fmt.Errorf("profile default: %w", sync.ErrController), exitController},
+		{"controller beats failed feeds", sync.RunInfo{FailedFeeds: failed}, sync.ErrController, exitController},
+		{"other error", sync.RunInfo{Held: true}, errors.New("failed to load allowlist"), exitError},
+	}
+	for _, tt := range tests {
+		if got := exitCode(tt.run, tt.err); got != tt.want {
+			t.Errorf("%s: exitCode = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
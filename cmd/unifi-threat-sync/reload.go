@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	gosync "sync"
+	"sync/atomic"
+
+	"github.com/0x4272616E646F6E/unifi-threat-sync/internal/config"
+	"github.com/0x4272616E646F6E/unifi-threat-sync/internal/http"
+	"github.com/0x4272616E646F6E/unifi-threat-sync/internal/manual"
+	"github.com/0x4272616E646F6E/unifi-threat-sync/internal/scheduler"
+	"github.com/0x4272616E646F6E/unifi-threat-sync/internal/sync"
+)
+
+// reloader re-reads the configuration file on request. Valid configurations
+// are held as pending and swapped in by the sync loop between runs; invalid
+// ones are rejected and the active configuration stays in place.
+type reloader struct {
+	path    string
+	startup *config.Config
+	health  *http.HealthServer
+	manual  *manual.Store
+	trigger func()
+	mu      gosync.Mutex
+	pending atomic.Pointer[config.Config]
+}
+
+// restartOnly lists the sections that are wired up once at startup. Changes
+// to them are logged and ignored until the service is restarted.
+var restartOnly = []struct {
+	name string
+	get  func(c *config.Config) interface{}
+	set  func(dst, src *config.Config)
+}{
+	{"unifi", func(c *config.Config) interface{} { return c.UniFi }, func(d, s *config.Config) { d.UniFi = s.UniFi }},
+	{"health", func(c *config.Config) interface{} { return c.Health }, func(d, s *config.Config) { d.Health = s.Health }},
+	{"api", func(c *config.Config) interface{} { return c.API }, func(d, s *config.Config) { d.API = s.API }},
+	{"manual", func(c *config.Config) interface{} { return c.Manual }, func(d, s *config.Config) { d.Manual = s.Manual }},
+	{"notify", func(c *config.Config) interface{} { return c.Notify }, func(d, s *config.Config) { d.Notify = s.Notify }},
+	{"audit", func(c *config.Config) interface{} { return c.Audit }, func(d, s *config.Config) { d.Audit = s.Audit }},
+	{"leader", func(c *config.Config) interface{} { return c.Leader }, func(d, s *config.Config) { d.Leader = s.Leader }},
+	{"reload", func(c *config.Config) interface{} { return c.Reload }, func(d, s *config.Config) { d.Reload = s.Reload }},
+}
+
+// reload loads and validates the configuration file and, if it is valid,
+// queues it to be applied before the next sync run, which it triggers
+func (r *reloader) reload(reason string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	fmt.Printf("Reloading configuration (%s)...\n", reason)
+
+	cfg, err := loadConfig(r.path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Configuration reload rejected, keeping current configuration: %v\n", err)
+		if r.health != nil {
+			r.health.RecordReload("", err)
+		}
+		return
+	}
+
+	for _, section := range restartOnly {
+		if !reflect.DeepEqual(section.get(cfg), section.get(r.startup)) {
+			fmt.Printf("Warning: changes to '%s' require a restart and were not applied\n", section.name)
+		}
+		section.set(cfg, r.startup)
+	}
+
+	r.pending.Store(cfg)
+	if r.health != nil {
+		r.health.RecordReload(cfg.Hash(), nil)
+	}
+	fmt.Printf("Configuration reloaded (hash %.12s), applying before next sync\n", cfg.Hash())
+	r.trigger()
+}
+
+// apply swaps in a pending configuration, if any. It must be called between
+// sync runs.
+func (r *reloader) apply(sched *scheduler.Scheduler, syncer *sync.Syncer) {
+	cfg := r.pending.Swap(nil)
+	if cfg == nil {
+		return
+	}
+
+	// Already validated by loadConfig
+	if err := sched.Reconfigure(cfg.Sync); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to apply schedule, keeping current configuration: %v\n", err)
+		return
+	}
+	syncer.SetConfig(cfg)
+	if r.manual != nil {
+		r.manual.SetPolicy(manual.PolicyFrom(cfg.Filter))
+	}
+	fmt.Printf("Applied configuration %.12s (schedule: %s, enabled feeds: %d)\n", cfg.Hash(), sched, cfg.Feeds.EnabledCount())
+}
+
+// loadConfig loads and fully validates a configuration file
+func loadConfig(path string) (*config.Config, error) {
+	cfg, err := config.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	if _, err := scheduler.New(cfg.Sync); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	return cfg, nil
+}
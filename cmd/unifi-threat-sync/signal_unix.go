@@ -0,0 +1,11 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// syncSignals request an immediate sync run
+var syncSignals = []os.Signal{syscall.SIGUSR1}
@@ -0,0 +1,6 @@
+package main
+
+import "os"
+
+// syncSignals request an immediate sync run; Windows has no SIGUSR1
+var syncSignals []os.Signal
@@ -0,0 +1,249 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Record is one controller mutation
+type Record struct {
+	Timestamp    time.Time `json:"timestamp"`
+	Action       string    `json:"action"` // create, update, rewrite (after a failed verify), create-rule or update-rule
+	Site         string    `json:"site"`
+	Profile      string    `json:"profile"`
+	Group        string    `json:"group"`
+	AddedCount   int       `json:"addedCount"`
+	RemovedCount int       `json:"removedCount"`
+	Added        []string  `json:"added"`
+	Removed      []string  `json:"removed"`
+	AddedAddrs   string    `json:"addedAddresses,omitempty"`   // Addresses newly blocked, in decimal
+	RemovedAddrs string    `json:"removedAddresses,omitempty"` // Addresses no longer blocked, in decimal
+	Feeds        []string  `json:"feeds"`
+	ConfigHash   string    `json:"configHash"`
+	Error        string    `json:"error,omitempty"` // Set when the mutation failed
+}
+
+// Logger appends records to a JSON Lines file and rotates it by size or age
+type Logger struct {
+	mu         sync.Mutex
+	path       string
+	maxSize    int64
+	maxAge     time.Duration
+	maxBackups int
+	file       *os.File
+	size       int64
+	created    time.Time
+}
+
+// New creates a Logger writing to path. Zero maxSize or maxAge disables that
+// rotation trigger; maxBackups rotated files are kept (zero keeps all).
+func New(path string, maxSize int64, maxAge time.Duration, maxBackups int) *Logger {
+	return &Logger{
+		path:       path,
+		maxSize:    maxSize,
+		maxAge:     maxAge,
+		maxBackups: maxBackups,
+	}
+}
+
+// Write appends a record, rotating the file first if it is due
+func (l *Logger) Write(rec Record) error {
+	if rec.Timestamp.IsZero() {
+		rec.Timestamp = time.Now().UTC()
+	}
+
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit record: %w", err)
+	}
+	line = append(line, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := l.open(); err != nil {
+		return err
+	}
+	if l.rotationDue(rec.Timestamp, int64(len(line))) {
+		if err := l.rotate(); err != nil {
+			return err
+		}
+		if err := l.open(); err != nil {
+			return err
+		}
+	}
+
+	n, err := l.file.Write(line)
+	l.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("failed to write audit record: %w", err)
+	}
+	if l.created.IsZero() {
+		l.created = rec.Timestamp
+	}
+	return nil
+}
+
+// Close closes the current log file
+func (l *Logger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
+
+// open opens the current log file for appending if it is not open yet; the
+// caller must hold l.mu
+func (l *Logger) open() error {
+	if l.file != nil {
+		return nil
+	}
+
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o640)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("failed to stat audit log: %w", err)
+	}
+
+	l.file = f
+	l.size = info.Size()
+	l.created = firstTimestamp(l.path)
+	return nil
+}
+
+// firstTimestamp returns the timestamp of the first record in a file, or the
+// zero time if there is none
+func firstTimestamp(path string) time.Time {
+	f, err := os.Open(path)
+	if err != nil {
+		return time.Time{}
+	}
+	defer f.Close()
+
+	var rec struct {
+		Timestamp time.Time `json:"timestamp"`
+	}
+	if err := json.NewDecoder(f).Decode(&rec); err != nil {
+		return time.Time{}
+	}
+	return rec.Timestamp
+}
+
+// rotationDue reports whether writing n more bytes at t should start a new
+// file; the caller must hold l.mu
+func (l *Logger) rotationDue(t time.Time, n int64) bool {
+	if l.size == 0 {
+		return false
+	}
+	if l.maxSize > 0 && l.size+n > l.maxSize {
+		return true
+	}
+	return l.maxAge > 0 && !l.created.IsZero() && t.Sub(l.created) >= l.maxAge
+}
+
+// rotate renames the current file with a timestamp suffix and prunes old
+// backups; the caller must hold l.mu
+func (l *Logger) rotate() error {
+	if err := l.file.Close(); err != nil {
+		return fmt.Errorf("failed to close audit log: %w", err)
+	}
+	l.file = nil
+
+	ext := filepath.Ext(l.path)
+	backup := fmt.Sprintf("%s-%s%s", strings.TrimSuffix(l.path, ext), time.Now().UTC().Format("20060102T150405.000"), ext)
+	if err := os.Rename(l.path, backup); err != nil {
+		return fmt.Errorf("failed to rotate audit log: %w", err)
+	}
+
+	if l.maxBackups > 0 {
+		backups, err := backupFiles(l.path)
+		if err != nil {
+			return err
+		}
+		for len(backups) > l.maxBackups {
+			os.Remove(backups[0])
+			backups = backups[1:]
+		}
+	}
+	return nil
+}
+
+// Read returns the most recent records across the current and rotated files,
+// oldest first. A limit of zero returns everything.
+func Read(path string, limit int) ([]Record, error) {
+	files, err := backupFiles(path)
+	if err != nil {
+		return nil, err
+	}
+	files = append(files, path)
+
+	var records []Record
+	for _, f := range files {
+		recs, err := readFile(f)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, recs...)
+	}
+
+	if limit > 0 && len(records) > limit {
+		records = records[len(records)-limit:]
+	}
+	return records, nil
+}
+
+// backupFiles lists rotated files for path, oldest first
+func backupFiles(path string) ([]string, error) {
+	ext := filepath.Ext(path)
+	matches, err := filepath.Glob(strings.TrimSuffix(path, ext) + "-*" + ext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit logs: %w", err)
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
+
+// readFile parses all records in one JSON Lines file
+func readFile(path string) ([]Record, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		var rec Record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			// Skip partially written lines
+			continue
+		}
+		records = append(records, rec)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log %s: %w", path, err)
+	}
+	return records, nil
+}
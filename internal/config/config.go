@@ -1,20 +1,201 @@
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"os"
 	"strings"
 	"time"
 
 	"gopkg.in/yaml.v3"
+
+	"github.com/0x4272616E646F6E/unifi-threat-sync/internal/indicator"
 )
 
 // Config represents the entire application configuration
 type Config struct {
-	UniFi  UniFiConfig  `yaml:"unifi"`
-	Sync   SyncConfig   `yaml:"sync"`
-	Feeds  FeedsList    `yaml:"feeds"`
-	Health HealthConfig `yaml:"health"`
+	UniFi     UniFiConfig     `yaml:"unifi"`
+	Sync      SyncConfig      `yaml:"sync"`
+	Feeds     FeedsList       `yaml:"feeds"`
+	Profiles  []ProfileConfig `yaml:"profiles"`
+	Allowlist AllowlistConfig `yaml:"allowlist"`
+	Filter    FilterConfig    `yaml:"filter"`
+	Health    HealthConfig    `yaml:"health"`
+	API       APIConfig       `yaml:"api"`
+	Manual    ManualConfig    `yaml:"manual"`
+	Notify    NotifyConfig    `yaml:"notify"`
+	Audit     AuditConfig     `yaml:"audit"`
+	Reload    ReloadConfig    `yaml:"reload"`
+	Leader    LeaderConfig    `yaml:"leader"`
+
+	hash string // SHA256 of the config file before env expansion
+}
+
+// LeaderConfig holds settings for active/standby operation of several
+// replicas. Only the replica holding the lease syncs.
+type LeaderConfig struct {
+	Enabled       bool          `yaml:"enabled"`
+	Path          string        `yaml:"path"`          // Lease file on storage shared by all replicas
+	ID            string        `yaml:"id"`            // Name of this replica (default: hostname)
+	LeaseDuration time.Duration `yaml:"leaseDuration"` // How long a lease lasts without renewal
+	RenewInterval time.Duration `yaml:"renewInterval"` // How often the lease is renewed or campaigned for
+}
+
+// ReloadConfig holds settings for reloading the configuration while running
+type ReloadConfig struct {
+	Watch    bool          `yaml:"watch"`    // Reload when the file changes, not only on SIGHUP
+	Interval time.Duration `yaml:"interval"` // How often the file is checked for changes
+}
+
+// AuditConfig holds settings for the audit log of controller changes
+type AuditConfig struct {
+	Enabled    bool          `yaml:"enabled"`
+	Path       string        `yaml:"path"`       // JSON Lines file
+	MaxSizeMB  *int          `yaml:"maxSizeMB"`  // Rotate when the file exceeds this size (default: 10, zero = no limit)
+	MaxAge     time.Duration `yaml:"maxAge"`     // Rotate when the file is older than this (zero = no limit)
+	MaxBackups *int          `yaml:"maxBackups"` // Rotated files to keep (default: 10, zero = keep all)
+}
+
+// MaxSize returns the size in bytes at which the audit log is rotated
+func (a AuditConfig) MaxSize() int64 {
+	if a.MaxSizeMB == nil {
+		return 10 << 20
+	}
+	return int64(*a.MaxSizeMB) << 20
+}
+
+// Backups returns how many rotated audit logs to keep
+func (a AuditConfig) Backups() int {
+	if a.MaxBackups == nil {
+		return 10
+	}
+	return *a.MaxBackups
+}
+
+// NotifyConfig holds outbound notification settings
+type NotifyConfig struct {
+	LargeChange *int           `yaml:"largeChange"` // Added+removed entries that count as a large change (default: 1000)
+	Targets     []NotifyTarget `yaml:"targets"`
+}
+
+// LargeChangeThreshold returns how many added plus removed entries make a
+// push a large change; zero makes every push one
+func (n NotifyConfig) LargeChangeThreshold() int {
+	if n.LargeChange == nil {
+		return 1000
+	}
+	return *n.LargeChange
+}
+
+// NotifyTarget is a webhook, Slack or Microsoft Teams destination
+type NotifyTarget struct {
+	Name       string            `yaml:"name"`
+	Type       string            `yaml:"type"` // webhook, slack or teams
+	URL        string            `yaml:"url"`
+	Events     []string          `yaml:"events"`   // failure, safety, largeChange, recovery (empty = all)
+	Template   string            `yaml:"template"` // Go text/template for webhook bodies
+	Headers    map[string]string `yaml:"headers"`
+	Retries    *int              `yaml:"retries"` // Default: 3
+	RetryDelay time.Duration     `yaml:"retryDelay"`
+}
+
+// RetryCount returns how many times a failed delivery is retried; zero sends
+// each event once
+func (t NotifyTarget) RetryCount() int {
+	if t.Retries == nil {
+		return 3
+	}
+	return *t.Retries
+}
+
+// APIConfig holds settings for the authenticated management API served on
+// the health port
+type APIConfig struct {
+	Token string `yaml:"token"` // Bearer token required by /api endpoints
+}
+
+// ManualConfig holds settings for manually blocked entries
+type ManualConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Path    string `yaml:"path"` // JSON file the entries are persisted to
+}
+
+// FilterConfig holds settings for entries dropped from feeds before merging
+type FilterConfig struct {
+	Reserved *bool        `yaml:"reserved"` // Drop IANA special-purpose ranges (default: true)
+	Prefix   PrefixPolicy `yaml:"prefix"`   // Minimum prefix lengths for all feeds
+}
+
+// PrefixPolicy sets the shortest prefix lengths accepted from a feed. Zero
+// values in a feed's policy fall back to filter.prefix.
+type PrefixPolicy struct {
+	MinIPv4  int    `yaml:"minIPv4"`  // 0 = no limit
+	MinIPv6  int    `yaml:"minIPv6"`  // 0 = no limit
+	Action   string `yaml:"action"`   // reject (default) | split
+	MaxSplit int    `yaml:"maxSplit"` // Most prefixes one entry is split into (default: 256)
+}
+
+// FilterReserved reports whether special-purpose ranges should be dropped
+func (f FilterConfig) FilterReserved() bool {
+	return f.Reserved == nil || *f.Reserved
+}
+
+// PolicyFor returns the prefix-length policy for a feed, with the feed's own
+// settings taking precedence over filter.prefix
+func (f FilterConfig) PolicyFor(feed FeedConfig) PrefixPolicy {
+	policy := f.Prefix
+	if feed.Prefix.MinIPv4 != 0 {
+		policy.MinIPv4 = feed.Prefix.MinIPv4
+	}
+	if feed.Prefix.MinIPv6 != 0 {
+		policy.MinIPv6 = feed.Prefix.MinIPv6
+	}
+	if feed.Prefix.Action != "" {
+		policy.Action = feed.Prefix.Action
+	}
+	if feed.Prefix.MaxSplit != 0 {
+		policy.MaxSplit = feed.Prefix.MaxSplit
+	}
+	return policy
+}
+
+// validate checks the policy; a feed's policy may leave fields unset
+func (p PrefixPolicy) validate(field string) error {
+	if p.MinIPv4 < 0 || p.MinIPv4 > 32 {
+		return fmt.Errorf("%s.minIPv4 must be between 0 and 32", field)
+	}
+	if p.MinIPv6 < 0 || p.MinIPv6 > 128 {
+		return fmt.Errorf("%s.minIPv6 must be between 0 and 128", field)
+	}
+	switch p.Action {
+	case "", "reject", "split":
+	default:
+		return fmt.Errorf("%s.action must be reject or split", field)
+	}
+	if p.MaxSplit < 0 {
+		return fmt.Errorf("%s.maxSplit must not be negative", field)
+	}
+	return nil
+}
+
+// ProfileConfig is a named output: a firewall group built from a selection
+// of feeds. Without configured profiles a single default profile built from
+// all feeds and the unifi group settings is used.
+type ProfileConfig struct {
+	Name      string   `yaml:"name"`
+	GroupName string   `yaml:"groupName"`
+	Ruleset   string   `yaml:"ruleset"`   // Ruleset of the drop rule created for the group
+	RuleIndex int      `yaml:"ruleIndex"` // Index of that rule (default unifi.ruleIndex plus the profile's position)
+	Feeds     []string `yaml:"feeds"`     // Feed names to include (empty = all enabled feeds)
+}
+
+// AllowlistConfig holds ranges that must never be blocked. Entries are
+// subtracted from the normalized blocklist before it is pushed.
+type AllowlistConfig struct {
+	CIDRs []string  `yaml:"cidrs"` // Static IPs/CIDRs
+	Files []string  `yaml:"files"` // Local files, one IP/CIDR per line
+	Feeds FeedsList `yaml:"feeds"` // Remote lists using the regular parsers
 }
 
 // UniFiConfig holds UniFi controller settings
@@ -30,7 +211,55 @@ type UniFiConfig struct {
 
 // SyncConfig holds synchronization settings
 type SyncConfig struct {
-	Interval time.Duration `yaml:"interval"`
+	Interval         time.Duration    `yaml:"interval"`
+	Concurrency      int              `yaml:"concurrency"`
+	Cron             string           `yaml:"cron"`
+	Jitter           time.Duration    `yaml:"jitter"`
+	Blackouts        []BlackoutWindow `yaml:"blackouts"`
+	GracePeriod      time.Duration    `yaml:"gracePeriod"`      // Keep entries blocked this long after they leave all feeds
+	MinAge           time.Duration    `yaml:"minAge"`           // Only block entries listed for at least this long
+	ScoreThreshold   float64          `yaml:"scoreThreshold"`   // Summed feed weight needed to block; zero blocks anything listed
+	MaxEntries       int              `yaml:"maxEntries"`       // Abort a push above this many entries (zero = no limit)
+	MaxRemovePercent float64          `yaml:"maxRemovePercent"` // Abort a push removing more than this share of the group (zero = no limit)
+	Verify           VerifyConfig     `yaml:"verify"`
+	Summarize        SummarizeConfig  `yaml:"summarize"`
+}
+
+// SummarizeConfig holds settings for lossy summarization of a profile's list
+// into fewer, wider prefixes
+type SummarizeConfig struct {
+	Target   int `yaml:"target"`   // Entry count to summarize down to (zero = off)
+	MaxWiden int `yaml:"maxWiden"` // Most bits any listed prefix may be widened by
+}
+
+// VerifyConfig holds settings for reading a group back after each write to
+// check the controller stored every member
+type VerifyConfig struct {
+	Enabled    *bool  `yaml:"enabled"`    // Verify writes (default: true)
+	OnMismatch string `yaml:"onMismatch"` // retry (default), fail or warn
+	Retries    *int   `yaml:"retries"`    // Rewrites before a retried mismatch fails the sync (default: 2)
+}
+
+// IsEnabled reports whether writes should be verified
+func (v VerifyConfig) IsEnabled() bool {
+	return v.Enabled == nil || *v.Enabled
+}
+
+// RetryCount returns how many rewrites a mismatch gets before it fails the
+// sync; zero fails on the first mismatch
+func (v VerifyConfig) RetryCount() int {
+	if v.Retries == nil {
+		return 2
+	}
+	return *v.Retries
+}
+
+// BlackoutWindow is a recurring period during which changes are computed but
+// not pushed to the controller
+type BlackoutWindow struct {
+	Days  []string `yaml:"days"`  // Weekdays the window starts on (empty = every day)
+	Start string   `yaml:"start"` // HH:MM local time
+	End   string   `yaml:"end"`   // HH:MM local time, may wrap past midnight
 }
 
 // HealthConfig holds health check server settings
@@ -41,13 +270,24 @@ type HealthConfig struct {
 
 // FeedConfig represents a single threat feed configuration
 type FeedConfig struct {
-	Name    string                 `yaml:"name"`
-	URL     string                 `yaml:"url"`
-	Parser  string                 `yaml:"parser"`
-	Enabled bool                   `yaml:"enabled"`
-	Timeout string                 `yaml:"timeout"`
-	Auth    map[string]interface{} `yaml:"auth"`
-	Params  map[string]interface{} `yaml:"params"`
+	Name     string                 `yaml:"name"`
+	URL      string                 `yaml:"url"`
+	Parser   string                 `yaml:"parser"`
+	Enabled  bool                   `yaml:"enabled"`
+	Timeout  string                 `yaml:"timeout"`
+	Interval time.Duration          `yaml:"interval"` // Refresh interval; zero refetches every sync
+	Weight   float64                `yaml:"weight"`   // Consensus weight (default: 1)
+	Prefix   PrefixPolicy           `yaml:"prefix"`   // Overrides filter.prefix for this feed
+	Auth     map[string]interface{} `yaml:"auth"`
+	Params   map[string]interface{} `yaml:"params"`
+}
+
+// GetTimeout returns the parsed feed timeout, falling back to 30s
+func (f FeedConfig) GetTimeout() time.Duration {
+	if t, err := time.ParseDuration(f.Timeout); err == nil && t > 0 {
+		return t
+	}
+	return 30 * time.Second
 }
 
 // FeedsList is a slice of FeedConfig with helper methods
@@ -75,6 +315,25 @@ func (f FeedsList) GetEnabled() []FeedConfig {
 	return enabled
 }
 
+// GetProfiles returns the configured output profiles, or the default profile
+// derived from the unifi settings when none are configured
+func (c *Config) GetProfiles() []ProfileConfig {
+	if len(c.Profiles) > 0 {
+		return c.Profiles
+	}
+	return []ProfileConfig{{
+		Name:      "default",
+		GroupName: c.UniFi.GroupName,
+		Ruleset:   c.UniFi.Ruleset,
+		RuleIndex: c.UniFi.RuleIndex,
+	}}
+}
+
+// Hash returns the SHA256 of the configuration file it was loaded from
+func (c *Config) Hash() string {
+	return c.hash
+}
+
 // Load reads and parses the configuration file
 func Load(path string) (*Config, error) {
 	data, err := os.ReadFile(path)
@@ -93,6 +352,10 @@ func Load(path string) (*Config, error) {
 	// Set defaults
 	cfg.setDefaults()
 
+	// Hash the unexpanded file so secrets from the environment don't leak
+	sum := sha256.Sum256(data)
+	cfg.hash = hex.EncodeToString(sum[:])
+
 	return &cfg, nil
 }
 
@@ -112,22 +375,96 @@ func (c *Config) setDefaults() {
 		c.UniFi.RuleIndex = 2000
 	}
 
+	// Profile defaults
+	for i := range c.Profiles {
+		if c.Profiles[i].Ruleset == "" {
+			c.Profiles[i].Ruleset = c.UniFi.Ruleset
+		}
+		if c.Profiles[i].RuleIndex == 0 {
+			c.Profiles[i].RuleIndex = c.UniFi.RuleIndex + i
+		}
+	}
+
 	// Sync defaults
 	if c.Sync.Interval == 0 {
 		c.Sync.Interval = 60 * time.Minute
 	}
+	if c.Sync.Concurrency == 0 {
+		c.Sync.Concurrency = 4
+	}
+	if c.Sync.Verify.OnMismatch == "" {
+		c.Sync.Verify.OnMismatch = "retry"
+	}
+	if c.Sync.Summarize.MaxWiden == 0 {
+		c.Sync.Summarize.MaxWiden = 8
+	}
+
+	// Filter defaults
+	if c.Filter.Prefix.Action == "" {
+		c.Filter.Prefix.Action = "reject"
+	}
+	if c.Filter.Prefix.MaxSplit == 0 {
+		c.Filter.Prefix.MaxSplit = 256
+	}
 
 	// Health defaults
 	if c.Health.Port == 0 {
 		c.Health.Port = 8080
 	}
 
+	// Notify defaults
+	for i := range c.Notify.Targets {
+		if c.Notify.Targets[i].Type == "" {
+			c.Notify.Targets[i].Type = "webhook"
+		}
+		if c.Notify.Targets[i].RetryDelay == 0 {
+			c.Notify.Targets[i].RetryDelay = 2 * time.Second
+		}
+	}
+
+	// Audit defaults
+	if c.Audit.Path == "" {
+		c.Audit.Path = "/data/audit.jsonl"
+	}
+
+	// Reload defaults
+	if c.Reload.Interval == 0 {
+		c.Reload.Interval = 10 * time.Second
+	}
+
+	// Leader election defaults
+	if c.Leader.Path == "" {
+		c.Leader.Path = "/data/leader.json"
+	}
+	if c.Leader.ID == "" {
+		c.Leader.ID, _ = os.Hostname()
+	}
+	if c.Leader.LeaseDuration == 0 {
+		c.Leader.LeaseDuration = 30 * time.Second
+	}
+	if c.Leader.RenewInterval == 0 {
+		c.Leader.RenewInterval = 10 * time.Second
+	}
+
+	// Manual entry defaults
+	if c.Manual.Path == "" {
+		c.Manual.Path = "/data/manual.json"
+	}
+
 	// Feed defaults
 	for i := range c.Feeds {
 		// Default enabled to true if not specified
 		if c.Feeds[i].Timeout == "" {
 			c.Feeds[i].Timeout = "30s"
 		}
+		if c.Feeds[i].Weight == 0 {
+			c.Feeds[i].Weight = 1
+		}
+	}
+	for i := range c.Allowlist.Feeds {
+		if c.Allowlist.Feeds[i].Timeout == "" {
+			c.Allowlist.Feeds[i].Timeout = "30s"
+		}
 	}
 }
 
@@ -151,6 +488,46 @@ func (c *Config) Validate() error {
 	if c.Sync.Interval < time.Minute {
 		return fmt.Errorf("sync.interval must be at least 1 minute")
 	}
+	if c.Sync.Concurrency < 1 {
+		return fmt.Errorf("sync.concurrency must be at least 1")
+	}
+	if c.Sync.Jitter < 0 {
+		return fmt.Errorf("sync.jitter must not be negative")
+	}
+	if c.Sync.GracePeriod < 0 {
+		return fmt.Errorf("sync.gracePeriod must not be negative")
+	}
+	if c.Sync.MinAge < 0 {
+		return fmt.Errorf("sync.minAge must not be negative")
+	}
+	if c.Sync.ScoreThreshold < 0 {
+		return fmt.Errorf("sync.scoreThreshold must not be negative")
+	}
+	if c.Sync.MaxEntries < 0 {
+		return fmt.Errorf("sync.maxEntries must not be negative")
+	}
+	if c.Sync.MaxRemovePercent < 0 || c.Sync.MaxRemovePercent > 100 {
+		return fmt.Errorf("sync.maxRemovePercent must be between 0 and 100")
+	}
+	switch c.Sync.Verify.OnMismatch {
+	case "retry", "fail", "warn":
+	default:
+		return fmt.Errorf("sync.verify.onMismatch must be retry, fail or warn")
+	}
+	if c.Sync.Verify.Retries != nil && *c.Sync.Verify.Retries < 0 {
+		return fmt.Errorf("sync.verify.retries must not be negative")
+	}
+	if c.Sync.Summarize.Target < 0 {
+		return fmt.Errorf("sync.summarize.target must not be negative")
+	}
+	if c.Sync.Summarize.MaxWiden < 0 || c.Sync.Summarize.MaxWiden > 128 {
+		return fmt.Errorf("sync.summarize.maxWiden must be between 0 and 128")
+	}
+
+	// Validate filters
+	if err := c.Filter.Prefix.validate("filter.prefix"); err != nil {
+		return err
+	}
 
 	// Validate feeds
 	if len(c.Feeds) == 0 {
@@ -158,29 +535,173 @@ func (c *Config) Validate() error {
 	}
 
 	enabledCount := 0
+	names := make(map[string]bool)
 	for i, feed := range c.Feeds {
 		if !feed.Enabled {
 			continue
 		}
 		enabledCount++
 
-		if feed.Name == "" {
-			return fmt.Errorf("feed[%d].name is required", i)
+		if err := feed.validate(fmt.Sprintf("feed[%d]", i), names); err != nil {
+			return err
+		}
+	}
+
+	if enabledCount == 0 {
+		return fmt.Errorf("at least one feed must be enabled")
+	}
+
+	// Validate notification targets
+	if c.Notify.LargeChange != nil && *c.Notify.LargeChange < 0 {
+		return fmt.Errorf("notify.largeChange must not be negative")
+	}
+	for i, t := range c.Notify.Targets {
+		if t.Name == "" {
+			return fmt.Errorf("notify.targets[%d].name is required", i)
+		}
+		switch t.Type {
+		case "webhook", "slack", "teams":
+		default:
+			return fmt.Errorf("notify.targets[%d].type must be webhook, slack or teams", i)
 		}
-		if feed.URL == "" {
-			return fmt.Errorf("feed[%d].url is required", i)
+		if !strings.HasPrefix(t.URL, "http://") && !strings.HasPrefix(t.URL, "https://") {
+			return fmt.Errorf("notify.targets[%d].url must start with http:// or https://", i)
 		}
-		if feed.Parser == "" {
-			return fmt.Errorf("feed[%d].parser is required", i)
+		for _, e := range t.Events {
+			switch e {
+			case "failure", "safety", "largeChange", "recovery":
+			default:
+				return fmt.Errorf("notify.targets[%d].events: unknown event %q", i, e)
+			}
 		}
-		if !strings.HasPrefix(feed.URL, "http://") && !strings.HasPrefix(feed.URL, "https://") {
-			return fmt.Errorf("feed[%d].url must start with http:// or https://", i)
+		if t.Retries != nil && *t.Retries < 0 {
+			return fmt.Errorf("notify.targets[%d].retries must not be negative", i)
 		}
 	}
 
-	if enabledCount == 0 {
-		return fmt.Errorf("at least one feed must be enabled")
+	// Validate audit log
+	if c.Audit.MaxSizeMB != nil && *c.Audit.MaxSizeMB < 0 {
+		return fmt.Errorf("audit.maxSizeMB must not be negative")
+	}
+	if c.Audit.MaxBackups != nil && *c.Audit.MaxBackups < 0 {
+		return fmt.Errorf("audit.maxBackups must not be negative")
+	}
+	if c.Audit.MaxAge < 0 {
+		return fmt.Errorf("audit.maxAge must not be negative")
+	}
+
+	// Validate reload settings
+	if c.Reload.Interval < 0 {
+		return fmt.Errorf("reload.interval must not be negative")
+	}
+
+	// Validate leader election
+	if c.Leader.Enabled {
+		if c.Leader.ID == "" {
+			return fmt.Errorf("leader.id is required when the hostname is unknown")
+		}
+		if c.Leader.RenewInterval <= 0 || c.Leader.RenewInterval >= c.Leader.LeaseDuration {
+			return fmt.Errorf("leader.renewInterval must be positive and shorter than leader.leaseDuration")
+		}
+	}
+
+	// Validate manual entries
+	if c.Manual.Enabled {
+		if !c.Health.Enabled {
+			return fmt.Errorf("manual.enabled requires health.enabled")
+		}
+		if c.API.Token == "" {
+			return fmt.Errorf("api.token is required when manual.enabled is set")
+		}
+	}
+
+	// Validate profiles
+	profileNames := make(map[string]bool)
+	groupNames := make(map[string]bool)
+	rules := make(map[string]bool)
+	for i, profile := range c.Profiles {
+		if profile.Name == "" {
+			return fmt.Errorf("profiles[%d].name is required", i)
+		}
+		if profileNames[profile.Name] {
+			return fmt.Errorf("profiles[%d].name %q is not unique", i, profile.Name)
+		}
+		profileNames[profile.Name] = true
+		if profile.GroupName == "" {
+			return fmt.Errorf("profiles[%d].groupName is required", i)
+		}
+		if groupNames[profile.GroupName] {
+			return fmt.Errorf("profiles[%d].groupName %q is used by another profile", i, profile.GroupName)
+		}
+		groupNames[profile.GroupName] = true
+		rule := fmt.Sprintf("%s/%d", profile.Ruleset, profile.RuleIndex)
+		if rules[rule] {
+			return fmt.Errorf("profiles[%d] ruleset %s index %d is used by another profile", i, profile.Ruleset, profile.RuleIndex)
+		}
+		rules[rule] = true
+		for _, feed := range profile.Feeds {
+			if !names[feed] {
+				return fmt.Errorf("profiles[%d].feeds references unknown or disabled feed %q", i, feed)
+			}
+		}
+	}
+
+	// Validate allowlist
+	for i, cidr := range c.Allowlist.CIDRs {
+		if !isIPOrCIDR(cidr) {
+			return fmt.Errorf("allowlist.cidrs[%d] is not a valid IP or CIDR: %s", i, cidr)
+		}
+	}
+	for i, path := range c.Allowlist.Files {
+		if path == "" {
+			return fmt.Errorf("allowlist.files[%d] must not be empty", i)
+		}
+	}
+	for i, feed := range c.Allowlist.Feeds {
+		if !feed.Enabled {
+			continue
+		}
+		if err := feed.validate(fmt.Sprintf("allowlist.feeds[%d]", i), names); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
+
+// validate checks a single feed definition; names tracks feed names already
+// in use so duplicates across feed lists are rejected
+func (f FeedConfig) validate(field string, names map[string]bool) error {
+	if f.Name == "" {
+		return fmt.Errorf("%s.name is required", field)
+	}
+	if names[f.Name] {
+		return fmt.Errorf("%s.name %q is not unique", field, f.Name)
+	}
+	names[f.Name] = true
+	if f.URL == "" {
+		return fmt.Errorf("%s.url is required", field)
+	}
+	if f.Parser == "" {
+		return fmt.Errorf("%s.parser is required", field)
+	}
+	if !strings.HasPrefix(f.URL, "http://") && !strings.HasPrefix(f.URL, "https://") {
+		return fmt.Errorf("%s.url must start with http:// or https://", field)
+	}
+	if _, err := time.ParseDuration(f.Timeout); err != nil {
+		return fmt.Errorf("%s.timeout is invalid: %w", field, err)
+	}
+	if f.Interval < 0 {
+		return fmt.Errorf("%s.interval must not be negative", field)
+	}
+	if f.Weight < 0 {
+		return fmt.Errorf("%s.weight must not be negative", field)
+	}
+	return f.Prefix.validate(field + ".prefix")
+}
+
+// isIPOrCIDR checks if a string is a valid IP address or CIDR block
+func isIPOrCIDR(s string) bool {
+	_, err := indicator.Parse(s)
+	return err == nil
+}
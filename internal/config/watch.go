@@ -0,0 +1,37 @@
+package config
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"time"
+)
+
+// Watch checks the configuration file every interval and calls onChange when
+// its contents no longer match hash, until ctx is cancelled. Contents are
+// compared rather than modification times so Kubernetes ConfigMap updates,
+// which swap a symlink, are picked up too. Read errors are ignored; the file
+// is checked again on the next tick.
+func Watch(ctx context.Context, path, hash string, interval time.Duration, onChange func()) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		sum := sha256.Sum256(data)
+		if current := hex.EncodeToString(sum[:]); current != hash {
+			hash = current
+			onChange()
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package http
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/0x4272616E646F6E/unifi-threat-sync/internal/audit"
+	"github.com/0x4272616E646F6E/unifi-threat-sync/internal/manual"
+)
+
+// manualRequest is the body of POST /api/manual
+type manualRequest struct {
+	IP     string `json:"ip"`
+	Reason string `json:"reason"`
+	TTL    string `json:"ttl"`  // Go duration, empty = until removed
+	Sync   bool   `json:"sync"` // Trigger a sync right away
+}
+
+// apiError is the body of an API error response
+type apiError struct {
+	Error string `json:"error"`
+}
+
+// EnableAPI sets the bearer token for the management endpoints. trigger is
+// called to request an immediate sync and may be nil.
+func (hs *HealthServer) EnableAPI(token string, trigger func()) {
+	hs.apiToken = token
+	hs.triggerSync = trigger
+}
+
+// EnableManual registers the manual block endpoints
+func (hs *HealthServer) EnableManual(store *manual.Store) {
+	hs.manualStore = store
+	hs.mux.HandleFunc("/api/manual", hs.requireToken(hs.handleManual))
+}
+
+// EnableAudit registers the audit log endpoint for the log at path
+func (hs *HealthServer) EnableAudit(path string) {
+	hs.auditPath = path
+	hs.mux.HandleFunc("/api/audit", hs.requireToken(hs.handleAudit))
+}
+
+// EnableLookup registers the /api/lookup endpoint; lookup returns the JSON
+// response describing why an address is blocked
+func (hs *HealthServer) EnableLookup(lookup func(addr netip.Addr) interface{}) {
+	hs.lookup = lookup
+	hs.mux.HandleFunc("/api/lookup", hs.requireToken(hs.handleLookup))
+}
+
+// EnableSync registers the /api/sync endpoint. request starts an on-demand
+// run and returns its ID and JSON response, or an error when this instance
+// cannot run syncs; run returns the run with the given ID and runs returns
+// the recent runs.
+func (hs *HealthServer) EnableSync(request func() (string, interface{}, error), run func(id string) (interface{}, bool), runs func() interface{}) {
+	hs.requestRun = request
+	hs.syncRun = run
+	hs.syncRuns = runs
+	hs.mux.HandleFunc("/api/sync", hs.requireToken(hs.handleSync))
+}
+
+// requireToken rejects requests without a valid bearer token
+func (hs *HealthServer) requireToken(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || hs.apiToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(hs.apiToken)) != 1 {
+			writeJSON(w, http.StatusUnauthorized, apiError{Error: "unauthorized"})
+			return
+		}
+		next(w, r)
+	}
+}
+
+// handleManual handles the /api/manual endpoint
+func (hs *HealthServer) handleManual(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, hs.manualStore.List())
+
+	case http.MethodPost:
+		var req manualRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, apiError{Error: "invalid request body"})
+			return
+		}
+
+		var ttl time.Duration
+		if req.TTL != "" {
+			var err error
+			if ttl, err = time.ParseDuration(req.TTL); err != nil {
+				writeJSON(w, http.StatusBadRequest, apiError{Error: "invalid ttl: " + err.Error()})
+				return
+			}
+		}
+
+		entry, err := hs.manualStore.Add(req.IP, req.Reason, ttl)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, apiError{Error: err.Error()})
+			return
+		}
+		if req.Sync {
+			hs.requestSync()
+		}
+		writeJSON(w, http.StatusCreated, entry)
+
+	case http.MethodDelete:
+		err := hs.manualStore.Remove(r.URL.Query().Get("ip"))
+		if errors.Is(err, manual.ErrNotFound) {
+			writeJSON(w, http.StatusNotFound, apiError{Error: err.Error()})
+			return
+		}
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, apiError{Error: err.Error()})
+			return
+		}
+		if r.URL.Query().Get("sync") == "true" {
+			hs.requestSync()
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleAudit handles the /api/audit endpoint
+func (hs *HealthServer) handleAudit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	limit := 100
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeJSON(w, http.StatusBadRequest, apiError{Error: "invalid limit"})
+			return
+		}
+		limit = n
+	}
+
+	records, err := audit.Read(hs.auditPath, limit)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, apiError{Error: err.Error()})
+		return
+	}
+	if records == nil {
+		records = []audit.Record{}
+	}
+	writeJSON(w, http.StatusOK, records)
+}
+
+// handleLookup handles the /api/lookup endpoint
+func (hs *HealthServer) handleLookup(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	addr, err := netip.ParseAddr(strings.TrimSpace(r.URL.Query().Get("ip")))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, apiError{Error: "ip must be a valid IP address"})
+		return
+	}
+	writeJSON(w, http.StatusOK, hs.lookup(addr.WithZone("").Unmap()))
+}
+
+// handleSync handles the /api/sync endpoint
+func (hs *HealthServer) handleSync(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		id, run, err := hs.requestRun()
+		if err != nil {
+			writeJSON(w, http.StatusServiceUnavailable, apiError{Error: err.Error()})
+			return
+		}
+		w.Header().Set("Location", "/api/sync?id="+id)
+		writeJSON(w, http.StatusAccepted, run)
+
+	case http.MethodGet:
+		id := r.URL.Query().Get("id")
+		if id == "" {
+			writeJSON(w, http.StatusOK, hs.syncRuns())
+			return
+		}
+		run, ok := hs.syncRun(id)
+		if !ok {
+			writeJSON(w, http.StatusNotFound, apiError{Error: "run not found"})
+			return
+		}
+		writeJSON(w, http.StatusOK, run)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// requestSync asks the main loop for an immediate sync
+func (hs *HealthServer) requestSync() {
+	if hs.triggerSync != nil {
+		hs.triggerSync()
+	}
+}
+
+// writeJSON writes v as a JSON response with the given status code
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
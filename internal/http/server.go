@@ -4,9 +4,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/netip"
 	"net/http"
+	"sort"
+	"sync"
 	"sync/atomic"
 	"time"
+
+	"github.com/0x4272616E646F6E/unifi-threat-sync/internal/manual"
 )
 
 // HealthServer provides health check endpoints
@@ -18,8 +23,28 @@ type HealthServer struct {
 	lastSync    atomic.Value // stores time.Time
 	syncCount   atomic.Int64
 	errorCount  atomic.Int64
+	reloadOK    atomic.Int64
+	reloadFail  atomic.Int64
+	lastReload  atomic.Value // stores ReloadStatus
+	election    atomic.Bool
+	leader      atomic.Bool
 	version     string
 	startTime   time.Time
+	feedsMu     sync.RWMutex
+	feeds       map[string]FeedStatus
+	verifyMu    sync.Mutex
+	verify      map[string]*verifyStatus
+	summaryMu   sync.Mutex
+	summaries   map[string]summaryStatus
+	mux         *http.ServeMux
+	apiToken    string
+	manualStore *manual.Store
+	auditPath   string
+	lookup      func(addr netip.Addr) interface{}
+	triggerSync func()
+	requestRun  func() (string, interface{}, error)
+	syncRun     func(id string) (interface{}, bool)
+	syncRuns    func() interface{}
 }
 
 // HealthStatus represents the health check response
@@ -30,12 +55,48 @@ type HealthStatus struct {
 	LastSync    string    `json:"lastSync,omitempty"`
 	SyncCount   int64     `json:"syncCount"`
 	ErrorCount  int64     `json:"errorCount"`
+	Feeds       []FeedStatus `json:"feeds,omitempty"`
+	LastReload  *ReloadStatus `json:"lastReload,omitempty"`
 	Timestamp   time.Time `json:"timestamp"`
 }
 
+// FeedStatus represents the refresh state of a single feed
+type FeedStatus struct {
+	Name        string     `json:"name"`
+	Entries     int        `json:"entries"`
+	Filtered    int        `json:"filtered"`
+	Rejected    int        `json:"rejected"` // Entries rejected by the prefix-length policy
+	Split       int        `json:"split"`    // Entries split by the prefix-length policy
+	LastFetch   *time.Time `json:"lastFetch,omitempty"`
+	NextRefresh time.Time  `json:"nextRefresh"`
+	LastError   string     `json:"lastError,omitempty"`
+}
+
+// verifyStatus holds the results of reading a group back after writes
+type verifyStatus struct {
+	mismatches int64 // Verifications that found a mismatch
+	missing    int   // Members missing in the last verification
+	unexpected int   // Unexpected members in the last verification
+}
+
+// summaryStatus holds the result of the last lossy summarization of a group
+type summaryStatus struct {
+	merges         int
+	extraAddresses float64
+}
+
+// ReloadStatus represents the outcome of the last configuration reload
+type ReloadStatus struct {
+	Time       time.Time `json:"time"`
+	Success    bool      `json:"success"`
+	ConfigHash string    `json:"configHash,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
 // ReadinessStatus represents the readiness check response
 type ReadinessStatus struct {
 	Ready     bool   `json:"ready"`
+	Role      string `json:"role,omitempty"` // leader or standby, with leader election
 	Message   string `json:"message,omitempty"`
 }
 
@@ -45,6 +106,9 @@ func NewHealthServer(port int, version string) *HealthServer {
 		port:      port,
 		version:   version,
 		startTime: time.Now(),
+		feeds:     make(map[string]FeedStatus),
+		verify:    make(map[string]*verifyStatus),
+		summaries: make(map[string]summaryStatus),
 	}
 	
 	// Initially healthy but not ready (until first sync)
@@ -52,6 +116,7 @@ func NewHealthServer(port int, version string) *HealthServer {
 	hs.ready.Store(false)
 	
 	mux := http.NewServeMux()
+	hs.mux = mux
 	mux.HandleFunc("/health", hs.handleHealth)
 	mux.HandleFunc("/healthz", hs.handleHealth) // Kubernetes alias
 	mux.HandleFunc("/ready", hs.handleReady)
@@ -107,6 +172,87 @@ func (hs *HealthServer) RecordError() {
 	hs.errorCount.Add(1)
 }
 
+// RecordFeedStatus records the refresh state of a feed
+func (hs *HealthServer) RecordFeedStatus(name string, count, filtered, rejected, split int, lastFetch, nextRefresh time.Time, err error) {
+	status := FeedStatus{
+		Name:        name,
+		Entries:     count,
+		Filtered:    filtered,
+		Rejected:    rejected,
+		Split:       split,
+		NextRefresh: nextRefresh,
+	}
+	if !lastFetch.IsZero() {
+		status.LastFetch = &lastFetch
+	}
+	if err != nil {
+		status.LastError = err.Error()
+	}
+
+	hs.feedsMu.Lock()
+	hs.feeds[name] = status
+	hs.feedsMu.Unlock()
+}
+
+// RecordVerify records the result of verifying a group after a write
+func (hs *HealthServer) RecordVerify(group string, missing, unexpected int) {
+	hs.verifyMu.Lock()
+	defer hs.verifyMu.Unlock()
+
+	status, ok := hs.verify[group]
+	if !ok {
+		status = &verifyStatus{}
+		hs.verify[group] = status
+	}
+	if missing > 0 || unexpected > 0 {
+		status.mismatches++
+	}
+	status.missing = missing
+	status.unexpected = unexpected
+}
+
+// RecordSummary records the result of summarizing a group's list
+func (hs *HealthServer) RecordSummary(group string, merges int, extraAddresses float64) {
+	hs.summaryMu.Lock()
+	hs.summaries[group] = summaryStatus{merges: merges, extraAddresses: extraAddresses}
+	hs.summaryMu.Unlock()
+}
+
+// SetLeader records whether this instance holds leadership. Once called,
+// readiness and metrics report the leadership state.
+func (hs *HealthServer) SetLeader(leader bool) {
+	hs.election.Store(true)
+	hs.leader.Store(leader)
+}
+
+// RecordReload records the outcome of a configuration reload
+func (hs *HealthServer) RecordReload(configHash string, err error) {
+	status := ReloadStatus{Time: time.Now(), Success: err == nil}
+	if err != nil {
+		status.Error = err.Error()
+		hs.reloadFail.Add(1)
+	} else {
+		status.ConfigHash = configHash
+		hs.reloadOK.Add(1)
+	}
+	hs.lastReload.Store(status)
+}
+
+// feedStatuses returns a snapshot of all feed states sorted by name
+func (hs *HealthServer) feedStatuses() []FeedStatus {
+	hs.feedsMu.RLock()
+	defer hs.feedsMu.RUnlock()
+
+	statuses := make([]FeedStatus, 0, len(hs.feeds))
+	for _, status := range hs.feeds {
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+	return statuses
+}
+
 // handleHealth handles the /health endpoint
 func (hs *HealthServer) handleHealth(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -120,6 +266,7 @@ func (hs *HealthServer) handleHealth(w http.ResponseWriter, r *http.Request) {
 		Uptime:     time.Since(hs.startTime).Round(time.Second).String(),
 		SyncCount:  hs.syncCount.Load(),
 		ErrorCount: hs.errorCount.Load(),
+		Feeds:      hs.feedStatuses(),
 		Timestamp:  time.Now(),
 	}
 	
@@ -128,6 +275,9 @@ func (hs *HealthServer) handleHealth(w http.ResponseWriter, r *http.Request) {
 			status.LastSync = time.Since(t).Round(time.Second).String() + " ago"
 		}
 	}
+	if reload, ok := hs.lastReload.Load().(ReloadStatus); ok {
+		status.LastReload = &reload
+	}
 	
 	if !hs.healthy.Load() {
 		status.Status = "unhealthy"
@@ -152,10 +302,25 @@ func (hs *HealthServer) handleReady(w http.ResponseWriter, r *http.Request) {
 		Ready: ready,
 	}
 	
-	if !ready {
+	standby := false
+	if hs.election.Load() {
+		standby = !hs.leader.Load()
+		status.Role = "leader"
+		if standby {
+			status.Role = "standby"
+		}
+	}
+	
+	switch {
+	case standby:
+		// A standby is ready to take over without having synced
+		status.Ready = true
+		status.Message = "Standby, waiting for leadership"
+		w.WriteHeader(http.StatusOK)
+	case !ready:
 		status.Message = "Waiting for first successful sync"
 		w.WriteHeader(http.StatusServiceUnavailable)
-	} else {
+	default:
 		status.Message = "Ready to serve"
 		w.WriteHeader(http.StatusOK)
 	}
@@ -190,6 +355,16 @@ func (hs *HealthServer) handleMetrics(w http.ResponseWriter, r *http.Request) {
 		fmt.Fprintf(w, "unifi_threat_sync_ready 0\n")
 	}
 	
+	if hs.election.Load() {
+		fmt.Fprintf(w, "# HELP unifi_threat_sync_leader Does this instance hold leadership\n")
+		fmt.Fprintf(w, "# TYPE unifi_threat_sync_leader gauge\n")
+		if hs.leader.Load() {
+			fmt.Fprintf(w, "unifi_threat_sync_leader 1\n")
+		} else {
+			fmt.Fprintf(w, "unifi_threat_sync_leader 0\n")
+		}
+	}
+	
 	fmt.Fprintf(w, "# HELP unifi_threat_sync_sync_total Total number of syncs\n")
 	fmt.Fprintf(w, "# TYPE unifi_threat_sync_sync_total counter\n")
 	fmt.Fprintf(w, "unifi_threat_sync_sync_total %d\n", hs.syncCount.Load())
@@ -198,6 +373,96 @@ func (hs *HealthServer) handleMetrics(w http.ResponseWriter, r *http.Request) {
 	fmt.Fprintf(w, "# TYPE unifi_threat_sync_errors_total counter\n")
 	fmt.Fprintf(w, "unifi_threat_sync_errors_total %d\n", hs.errorCount.Load())
 	
+	feeds := hs.feedStatuses()
+	fmt.Fprintf(w, "# HELP unifi_threat_sync_feed_entries Entries currently cached per feed\n")
+	fmt.Fprintf(w, "# TYPE unifi_threat_sync_feed_entries gauge\n")
+	for _, feed := range feeds {
+		fmt.Fprintf(w, "unifi_threat_sync_feed_entries{feed=%q} %d\n", feed.Name, feed.Entries)
+	}
+
+	fmt.Fprintf(w, "# HELP unifi_threat_sync_feed_filtered Reserved/bogon entries filtered in the last fetch per feed\n")
+	fmt.Fprintf(w, "# TYPE unifi_threat_sync_feed_filtered gauge\n")
+	for _, feed := range feeds {
+		fmt.Fprintf(w, "unifi_threat_sync_feed_filtered{feed=%q} %d\n", feed.Name, feed.Filtered)
+	}
+
+	fmt.Fprintf(w, "# HELP unifi_threat_sync_feed_rejected Entries rejected by the prefix-length policy in the last fetch per feed\n")
+	fmt.Fprintf(w, "# TYPE unifi_threat_sync_feed_rejected gauge\n")
+	for _, feed := range feeds {
+		fmt.Fprintf(w, "unifi_threat_sync_feed_rejected{feed=%q} %d\n", feed.Name, feed.Rejected)
+	}
+
+	fmt.Fprintf(w, "# HELP unifi_threat_sync_feed_split Entries split by the prefix-length policy in the last fetch per feed\n")
+	fmt.Fprintf(w, "# TYPE unifi_threat_sync_feed_split gauge\n")
+	for _, feed := range feeds {
+		fmt.Fprintf(w, "unifi_threat_sync_feed_split{feed=%q} %d\n", feed.Name, feed.Split)
+	}
+	
+	hs.verifyMu.Lock()
+	groups := make([]string, 0, len(hs.verify))
+	verify := make(map[string]verifyStatus, len(hs.verify))
+	for group, status := range hs.verify {
+		groups = append(groups, group)
+		verify[group] = *status
+	}
+	hs.verifyMu.Unlock()
+	sort.Strings(groups)
+
+	fmt.Fprintf(w, "# HELP unifi_threat_sync_verify_mismatches_total Group verifications that found members missing or unexpected\n")
+	fmt.Fprintf(w, "# TYPE unifi_threat_sync_verify_mismatches_total counter\n")
+	for _, group := range groups {
+		fmt.Fprintf(w, "unifi_threat_sync_verify_mismatches_total{group=%q} %d\n", group, verify[group].mismatches)
+	}
+
+	fmt.Fprintf(w, "# HELP unifi_threat_sync_verify_missing_members Members missing from the group in the last verification\n")
+	fmt.Fprintf(w, "# TYPE unifi_threat_sync_verify_missing_members gauge\n")
+	for _, group := range groups {
+		fmt.Fprintf(w, "unifi_threat_sync_verify_missing_members{group=%q} %d\n", group, verify[group].missing)
+	}
+
+	fmt.Fprintf(w, "# HELP unifi_threat_sync_verify_unexpected_members Unexpected group members in the last verification\n")
+	fmt.Fprintf(w, "# TYPE unifi_threat_sync_verify_unexpected_members gauge\n")
+	for _, group := range groups {
+		fmt.Fprintf(w, "unifi_threat_sync_verify_unexpected_members{group=%q} %d\n", group, verify[group].unexpected)
+	}
+
+	hs.summaryMu.Lock()
+	summaryGroups := make([]string, 0, len(hs.summaries))
+	summaries := make(map[string]summaryStatus, len(hs.summaries))
+	for group, status := range hs.summaries {
+		summaryGroups = append(summaryGroups, group)
+		summaries[group] = status
+	}
+	hs.summaryMu.Unlock()
+	sort.Strings(summaryGroups)
+
+	fmt.Fprintf(w, "# HELP unifi_threat_sync_summarize_merges Supernets created by the last summarization\n")
+	fmt.Fprintf(w, "# TYPE unifi_threat_sync_summarize_merges gauge\n")
+	for _, group := range summaryGroups {
+		fmt.Fprintf(w, "unifi_threat_sync_summarize_merges{group=%q} %d\n", group, summaries[group].merges)
+	}
+
+	fmt.Fprintf(w, "# HELP unifi_threat_sync_summarize_extra_addresses Addresses over-blocked by the last summarization\n")
+	fmt.Fprintf(w, "# TYPE unifi_threat_sync_summarize_extra_addresses gauge\n")
+	for _, group := range summaryGroups {
+		fmt.Fprintf(w, "unifi_threat_sync_summarize_extra_addresses{group=%q} %g\n", group, summaries[group].extraAddresses)
+	}
+
+	fmt.Fprintf(w, "# HELP unifi_threat_sync_config_reloads_total Configuration reloads by result\n")
+	fmt.Fprintf(w, "# TYPE unifi_threat_sync_config_reloads_total counter\n")
+	fmt.Fprintf(w, "unifi_threat_sync_config_reloads_total{result=\"success\"} %d\n", hs.reloadOK.Load())
+	fmt.Fprintf(w, "unifi_threat_sync_config_reloads_total{result=\"failure\"} %d\n", hs.reloadFail.Load())
+
+	if reload, ok := hs.lastReload.Load().(ReloadStatus); ok {
+		fmt.Fprintf(w, "# HELP unifi_threat_sync_config_last_reload_success Whether the last configuration reload succeeded\n")
+		fmt.Fprintf(w, "# TYPE unifi_threat_sync_config_last_reload_success gauge\n")
+		if reload.Success {
+			fmt.Fprintf(w, "unifi_threat_sync_config_last_reload_success 1\n")
+		} else {
+			fmt.Fprintf(w, "unifi_threat_sync_config_last_reload_success 0\n")
+		}
+	}
+
 	fmt.Fprintf(w, "# HELP unifi_threat_sync_uptime_seconds Uptime in seconds\n")
 	fmt.Fprintf(w, "# TYPE unifi_threat_sync_uptime_seconds gauge\n")
 	fmt.Fprintf(w, "unifi_threat_sync_uptime_seconds %.0f\n", time.Since(hs.startTime).Seconds())
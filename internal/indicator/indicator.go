@@ -0,0 +1,136 @@
+package indicator
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// Family is the address family of an indicator
+type Family int
+
+// Address families
+const (
+	IPv4 Family = 4
+	IPv6 Family = 6
+)
+
+// String returns "IPv4" or "IPv6"
+func (f Family) String() string {
+	if f == IPv4 {
+		return "IPv4"
+	}
+	return "IPv6"
+}
+
+// Indicator is an IP address or CIDR block in canonical form, together with
+// the corrections made to get there
+type Indicator struct {
+	Prefix   netip.Prefix // Masked; a single address is a /32 or /128
+	HostBits bool         // The input had host bits set, which were cleared
+	Mapped   bool         // The input was IPv4-mapped IPv6 and is now IPv4
+	Zone     string       // Zone the input IPv6 address carried, which was dropped
+}
+
+// Family returns the address family of the canonical prefix, so IPv4-mapped
+// input is IPv4
+func (ind Indicator) Family() Family {
+	if ind.Prefix.Addr().Is4() {
+		return IPv4
+	}
+	return IPv6
+}
+
+// Corrected reports whether the input had to be changed to be canonical
+func (ind Indicator) Corrected() bool {
+	return ind.HostBits || ind.Mapped || ind.Zone != ""
+}
+
+// Parse parses an IP address or CIDR block. IPv4-mapped IPv6 input
+// (::ffff:192.0.2.1, ::ffff:192.0.2.0/120) becomes the IPv4 address or
+// prefix it carries, host bits are cleared and IPv6 zones are dropped; each
+// correction is recorded in the result.
+func Parse(s string) (Indicator, error) {
+	s = strings.TrimSpace(s)
+
+	var ind Indicator
+	addrPart, bitsPart, isCIDR := strings.Cut(s, "/")
+	addrPart, zone, hasZone := strings.Cut(addrPart, "%")
+	if hasZone && zone == "" {
+		return Indicator{}, fmt.Errorf("invalid IP address: %s", s)
+	}
+	ind.Zone = zone
+
+	var p netip.Prefix
+	if isCIDR {
+		var err error
+		if p, err = netip.ParsePrefix(addrPart + "/" + bitsPart); err != nil {
+			return Indicator{}, fmt.Errorf("invalid CIDR: %s", s)
+		}
+	} else {
+		addr, err := netip.ParseAddr(addrPart)
+		if err != nil {
+			return Indicator{}, fmt.Errorf("invalid IP address: %s", s)
+		}
+		p = netip.PrefixFrom(addr, addr.BitLen())
+	}
+	if ind.Zone != "" && !p.Addr().Is6() {
+		return Indicator{}, fmt.Errorf("invalid IP address: %s", s)
+	}
+
+	if addr := p.Addr(); addr.Is4In6() {
+		if p.Bits() < 96 {
+			return Indicator{}, fmt.Errorf("invalid CIDR: %s: IPv4-mapped prefix shorter than /96", s)
+		}
+		p = netip.PrefixFrom(addr.Unmap(), p.Bits()-96)
+		ind.Mapped = true
+	}
+
+	ind.Prefix = p.Masked()
+	ind.HostBits = ind.Prefix != p
+	return ind, nil
+}
+
+// Report counts the corrections made while parsing a list of indicators
+type Report struct {
+	HostBits int // Entries whose host bits were cleared
+	Mapped   int // IPv4-mapped IPv6 entries turned into IPv4
+	Zones    int // IPv6 entries whose zone was dropped
+}
+
+// Add counts the corrections made to ind
+func (r *Report) Add(ind Indicator) {
+	if ind.HostBits {
+		r.HostBits++
+	}
+	if ind.Mapped {
+		r.Mapped++
+	}
+	if ind.Zone != "" {
+		r.Zones++
+	}
+}
+
+// Empty reports whether no corrections were counted
+func (r Report) Empty() bool {
+	return r.HostBits == 0 && r.Mapped == 0 && r.Zones == 0
+}
+
+// String describes the corrections, e.g. "2 with host bits set, 1
+// IPv4-mapped"
+func (r Report) String() string {
+	var parts []string
+	if r.HostBits > 0 {
+		parts = append(parts, fmt.Sprintf("%d with host bits set", r.HostBits))
+	}
+	if r.Mapped > 0 {
+		parts = append(parts, fmt.Sprintf("%d IPv4-mapped", r.Mapped))
+	}
+	if r.Zones > 0 {
+		parts = append(parts, fmt.Sprintf("%d with an IPv6 zone", r.Zones))
+	}
+	if len(parts) == 0 {
+		return "none"
+	}
+	return strings.Join(parts, ", ")
+}
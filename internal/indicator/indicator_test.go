@@ -0,0 +1,121 @@
+package indicator
+
+import (
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in       string
+		want     string
+		hostBits bool
+		mapped   bool
+		zone     string
+	}{
+		{in: "192.0.2.1", want: "192.0.2.1/32"},
+		{in: "192.0.2.0/24", want: "192.0.2.0/24"},
+		{in: "  192.0.2.0/24\r\n", want: "192.0.2.0/24"},
+		{in: "0.0.0.0/0", want: "0.0.0.0/0"},
+		{in: "1.2.3.4/24", want: "1.2.3.0/24", hostBits: true},
+		{in: "1.2.3.4/32", want: "1.2.3.4/32"},
+		{in: "2001:db8::1", want: "2001:db8::1/128"},
+		{in: "2001:db8::1/32", want: "2001:db8::/32", hostBits: true},
+
+		// IPv4-mapped IPv6 becomes IPv4, down to the /96 boundary
+		{in: "::ffff:1.2.3.4", want: "1.2.3.4/32", mapped: true},
+		{in: "::ffff:1.2.3.0/120", want: "1.2.3.0/24", mapped: true},
+		{in: "::ffff:1.2.3.4/120", want: "1.2.3.0/24", mapped: true, hostBits: true},
+		{in: "::ffff:0:0/96", want: "0.0.0.0/0", mapped: true},
+
+		// Zones are dropped, with or without a prefix length
+		{in: "fe80::1%eth0", want: "fe80::1/128", zone: "eth0"},
+		{in: "fe80::1%eth0/64", want: "fe80::/64", zone: "eth0", hostBits: true},
+		{in: "fe80::%2/64", want: "fe80::/64", zone: "2"},
+	}
+	for _, tt := range tests {
+		ind, err := Parse(tt.in)
+		if err != nil {
+			t.Errorf("Parse(%q) error: %v", tt.in, err)
+			continue
+		}
+		if want := netip.MustParsePrefix(tt.want); ind.Prefix != want {
+			t.Errorf("Parse(%q).Prefix = %s, want %s", tt.in, ind.Prefix, want)
+		}
+		if ind.HostBits != tt.hostBits || ind.Mapped != tt.mapped || ind.Zone != tt.zone {
+			t.Errorf("Parse(%q) corrections = hostBits %v, mapped %v, zone %q; want %v, %v, %q",
+				tt.in, ind.HostBits, ind.Mapped, ind.Zone, tt.hostBits, tt.mapped, tt.zone)
+		}
+		if corrected := tt.hostBits || tt.mapped || tt.zone != ""; ind.Corrected() != corrected {
+			t.Errorf("Parse(%q).Corrected() = %v, want %v", tt.in, ind.Corrected(), corrected)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"", "invalid IP address"},
+		{"not-an-ip", "invalid IP address"},
+		{"192.0.2.256", "invalid IP address"},
+		{"192.0.2.0/33", "invalid CIDR"},
+		{"192.0.2.0/", "invalid CIDR"},
+		{"2001:db8::/129", "invalid CIDR"},
+		{"::ffff:0:0/95", "shorter than /96"},
+		{"::ffff:1.2.3.0/64", "shorter than /96"},
+		{"fe80::1%", "invalid IP address"},
+		{"fe80::1%/64", "invalid IP address"},
+		{"192.0.2.1%eth0", "invalid IP address"},
+		{"192.0.2.0%eth0/24", "invalid IP address"},
+	}
+	for _, tt := range tests {
+		ind, err := Parse(tt.in)
+		if err == nil {
+			t.Errorf("Parse(%q) = %s, want an error", tt.in, ind.Prefix)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Parse(%q) error = %v, want %q", tt.in, err, tt.want)
+		}
+	}
+}
+
+func TestFamily(t *testing.T) {
+	tests := map[string]Family{
+		"192.0.2.1":          IPv4,
+		"::ffff:192.0.2.1":   IPv4,
+		"2001:db8::/32":      IPv6,
+		"fe80::1%eth0":       IPv6,
+		"::ffff:1.2.3.0/120": IPv4,
+	}
+	for in, want := range tests {
+		ind, err := Parse(in)
+		if err != nil {
+			t.Fatalf("Parse(%q) error: %v", in, err)
+		}
+		if got := ind.Family(); got != want {
+			t.Errorf("Parse(%q).Family() = %s, want %s", in, got, want)
+		}
+	}
+}
+
+func TestReport(t *testing.T) {
+	var r Report
+	if !r.Empty() || r.String() != "none" {
+		t.Errorf("empty report = %q, Empty %v", r, r.Empty())
+	}
+	for _, in := range []string{"192.0.2.1", "1.2.3.4/24", "10.0.0.1/8", "::ffff:1.2.3.4/120", "fe80::1%eth0"} {
+		ind, err := Parse(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.Add(ind)
+	}
+	want := "3 with host bits set, 1 IPv4-mapped, 1 with an IPv6 zone"
+	if r.Empty() || r.String() != want {
+		t.Errorf("report = %q, want %q", r, want)
+	}
+}
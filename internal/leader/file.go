@@ -0,0 +1,228 @@
+package leader
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// lockRetry is how long TryAcquire waits between attempts to take the lock
+// file guarding the lease
+const lockRetry = 50 * time.Millisecond
+
+// lease is the content of the lease file
+type lease struct {
+	Holder     string    `json:"holder"`
+	AcquiredAt time.Time `json:"acquiredAt"`
+	RenewedAt  time.Time `json:"renewedAt"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+}
+
+// FileLease is an Elector backed by a lease file on storage shared by all
+// replicas. The holder renews the lease before it expires; any other replica
+// may take it over once it has. Updates are serialized with a lock file
+// created next to the lease, so replicas must agree on the time to within a
+// small fraction of the lease duration.
+type FileLease struct {
+	path     string
+	id       string
+	duration time.Duration
+	now      func() time.Time
+	expires  time.Time // Expiry of the lease this instance last wrote
+}
+
+// NewFileLease creates a lease stored at path for the replica named id
+func NewFileLease(path, id string, duration time.Duration) *FileLease {
+	return &FileLease{
+		path:     path,
+		id:       id,
+		duration: duration,
+		now:      time.Now,
+	}
+}
+
+// TryAcquire takes the lease if it is free, expired or already ours, and
+// renews it in the latter case. When the lease file cannot be updated, a
+// holder keeps leadership until its last written lease expires, since no
+// other replica can take it over before then.
+func (f *FileLease) TryAcquire(ctx context.Context) (bool, error) {
+	held := f.now().Before(f.expires)
+
+	unlock, err := f.lock(ctx)
+	if err != nil {
+		return held, err
+	}
+	defer unlock()
+
+	current, err := f.read()
+	if err != nil {
+		return held, err
+	}
+
+	now := f.now()
+	if current != nil && current.Holder != f.id && now.Before(current.ExpiresAt) {
+		f.expires = time.Time{}
+		return false, nil
+	}
+
+	next := lease{
+		Holder:     f.id,
+		AcquiredAt: now,
+		RenewedAt:  now,
+		ExpiresAt:  now.Add(f.duration),
+	}
+	if current != nil && current.Holder == f.id && now.Before(current.ExpiresAt) {
+		next.AcquiredAt = current.AcquiredAt
+	}
+	if err := f.write(next); err != nil {
+		return held, err
+	}
+	f.expires = next.ExpiresAt
+	return true, nil
+}
+
+// Release removes the lease if this instance holds it
+func (f *FileLease) Release(ctx context.Context) error {
+	f.expires = time.Time{}
+
+	unlock, err := f.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	current, err := f.read()
+	if err != nil || current == nil || current.Holder != f.id {
+		return err
+	}
+	if err := os.Remove(f.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to release lease: %w", err)
+	}
+	return nil
+}
+
+// lock creates the lock file guarding the lease, waiting while another
+// replica holds it. The file holds a token unique to this attempt, so
+// unlocking never removes a lock taken over by another replica.
+func (f *FileLease) lock(ctx context.Context) (func(), error) {
+	path := f.path + ".lock"
+	token := fmt.Sprintf("%s %d %016x", f.id, time.Now().UnixNano(), rand.Uint64())
+	for {
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err == nil {
+			_, err = file.WriteString(token)
+			if closeErr := file.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				os.Remove(path)
+				return nil, fmt.Errorf("failed to lock lease: %w", err)
+			}
+			return func() { unlock(path, token) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("failed to lock lease: %w", err)
+		}
+
+		if f.breakStale(path) {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(lockRetry):
+		}
+	}
+}
+
+// breakStale removes a lock file older than the lease duration, left over
+// from a crashed replica, and reports whether it did. The file is renamed
+// aside before it is removed, so when several replicas see the same stale
+// lock only one of them takes it, and a fresh lock that replaced it in the
+// meantime is put back instead.
+func (f *FileLease) breakStale(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || f.now().Sub(info.ModTime()) <= f.duration {
+		return false
+	}
+	stale, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+
+	aside := fmt.Sprintf("%s.stale-%016x", path, rand.Uint64())
+	if err := os.Rename(path, aside); err != nil {
+		// Another replica moved it first
+		return false
+	}
+	defer os.Remove(aside)
+
+	taken, err := os.ReadFile(aside)
+	if err == nil && bytes.Equal(taken, stale) {
+		return true
+	}
+
+	// Link fails rather than replacing a lock created since the rename
+	if err := os.Link(aside, path); err != nil && !errors.Is(err, os.ErrExist) {
+		fmt.Printf("Warning: failed to restore lease lock: %v\n", err)
+	}
+	return false
+}
+
+// unlock removes the lock file if it still holds token
+func unlock(path, token string) {
+	if data, err := os.ReadFile(path); err == nil && string(data) == token {
+		os.Remove(path)
+	}
+}
+
+// read returns the current lease, or nil if there is none. An unreadable
+// lease is treated as free so a corrupted file cannot block every replica.
+func (f *FileLease) read() (*lease, error) {
+	data, err := os.ReadFile(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lease: %w", err)
+	}
+
+	var l lease
+	if err := json.Unmarshal(data, &l); err != nil {
+		return nil, nil
+	}
+	return &l, nil
+}
+
+// write replaces the lease file atomically
+func (f *FileLease) write(l lease) error {
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal lease: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), ".lease-*.json")
+	if err != nil {
+		return fmt.Errorf("failed to write lease: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write lease: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write lease: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), f.path); err != nil {
+		return fmt.Errorf("failed to write lease: %w", err)
+	}
+	return nil
+}
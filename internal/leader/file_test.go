@@ -0,0 +1,207 @@
+package leader
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testClock is a settable time source shared by the replicas in a test
+type testClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *testClock) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// newTestLease returns a lease in dir for replica id driven by clock
+func newTestLease(dir, id string, clock *testClock) *FileLease {
+	f := NewFileLease(filepath.Join(dir, "leader.json"), id, time.Minute)
+	f.now = clock.Now
+	return f
+}
+
+func mustAcquire(t *testing.T, f *FileLease, want bool) {
+	t.Helper()
+	got, err := f.TryAcquire(context.Background())
+	if err != nil {
+		t.Fatalf("%s: TryAcquire: %v", f.id, err)
+	}
+	if got != want {
+		t.Fatalf("%s: TryAcquire = %v, want %v", f.id, got, want)
+	}
+}
+
+func TestFileLeaseAcquireAndRenew(t *testing.T) {
+	dir := t.TempDir()
+	clock := &testClock{now: time.Now()}
+	a := newTestLease(dir, "a", clock)
+	b := newTestLease(dir, "b", clock)
+
+	mustAcquire(t, a, true)
+	mustAcquire(t, b, false)
+
+	first, err := a.read()
+	if err != nil || first == nil || first.Holder != "a" {
+		t.Fatalf("lease after acquire = %+v, %v", first, err)
+	}
+
+	clock.advance(30 * time.Second)
+	mustAcquire(t, a, true)
+	renewed, err := a.read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !renewed.AcquiredAt.Equal(first.AcquiredAt) {
+		t.Errorf("renewal changed AcquiredAt from %s to %s", first.AcquiredAt, renewed.AcquiredAt)
+	}
+	if !renewed.ExpiresAt.After(first.ExpiresAt) {
+		t.Errorf("renewal did not extend ExpiresAt past %s", first.ExpiresAt)
+	}
+
+	// Still held past the first expiry thanks to the renewal
+	clock.advance(45 * time.Second)
+	mustAcquire(t, b, false)
+
+	if _, err := os.Stat(a.path + ".lock"); !os.IsNotExist(err) {
+		t.Errorf("lock file left behind: %v", err)
+	}
+}
+
+func TestFileLeaseExpiry(t *testing.T) {
+	dir := t.TempDir()
+	clock := &testClock{now: time.Now()}
+	a := newTestLease(dir, "a", clock)
+	b := newTestLease(dir, "b", clock)
+
+	mustAcquire(t, a, true)
+	clock.advance(61 * time.Second)
+	mustAcquire(t, b, true)
+	mustAcquire(t, a, false)
+
+	l, err := a.read()
+	if err != nil || l == nil || l.Holder != "b" {
+		t.Fatalf("lease after takeover = %+v, %v", l, err)
+	}
+	if !l.AcquiredAt.Equal(clock.Now()) {
+		t.Errorf("takeover kept AcquiredAt %s", l.AcquiredAt)
+	}
+}
+
+func TestFileLeaseRelease(t *testing.T) {
+	dir := t.TempDir()
+	clock := &testClock{now: time.Now()}
+	a := newTestLease(dir, "a", clock)
+	b := newTestLease(dir, "b", clock)
+
+	mustAcquire(t, a, true)
+
+	// Releasing a lease held by someone else leaves it alone
+	if err := b.Release(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	mustAcquire(t, b, false)
+
+	if err := a.Release(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	mustAcquire(t, b, true)
+}
+
+func TestFileLeaseCorruptLease(t *testing.T) {
+	dir := t.TempDir()
+	clock := &testClock{now: time.Now()}
+	a := newTestLease(dir, "a", clock)
+
+	if err := os.WriteFile(a.path, []byte("not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	mustAcquire(t, a, true)
+}
+
+func TestFileLeaseWaitsForFreshLock(t *testing.T) {
+	dir := t.TempDir()
+	clock := &testClock{now: time.Now()}
+	a := newTestLease(dir, "a", clock)
+
+	if err := os.WriteFile(a.path+".lock", []byte("b"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*lockRetry)
+	defer cancel()
+	if held, err := a.TryAcquire(ctx); held || err == nil {
+		t.Fatalf("TryAcquire with a fresh lock = %v, %v; want false and an error", held, err)
+	}
+	if data, err := os.ReadFile(a.path + ".lock"); err != nil || string(data) != "b" {
+		t.Errorf("fresh lock was replaced: %q, %v", data, err)
+	}
+}
+
+func TestFileLeaseStaleLockTakeover(t *testing.T) {
+	dir := t.TempDir()
+	lockPath := filepath.Join(dir, "leader.json.lock")
+
+	// Several replicas find the same stale lock at once; only one may hold
+	// the lock at any time
+	for round := 0; round < 5; round++ {
+		if err := os.WriteFile(lockPath, []byte("crashed"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		old := time.Now().Add(-time.Hour)
+		if err := os.Chtimes(lockPath, old, old); err != nil {
+			t.Fatal(err)
+		}
+
+		var inside, maxInside atomic.Int32
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			f := NewFileLease(filepath.Join(dir, "leader.json"), "replica", time.Minute)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				unlock, err := f.lock(context.Background())
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				n := inside.Add(1)
+				for {
+					m := maxInside.Load()
+					if n <= m || maxInside.CompareAndSwap(m, n) {
+						break
+					}
+				}
+				time.Sleep(time.Millisecond)
+				inside.Add(-1)
+				unlock()
+			}()
+		}
+		wg.Wait()
+
+		if m := maxInside.Load(); m != 1 {
+			t.Fatalf("round %d: %d replicas held the lock at once", round, m)
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 0 {
+			t.Fatalf("round %d: files left behind: %v", round, entries)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package leader
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Elector decides which of several replicas is the leader
+type Elector interface {
+	// TryAcquire makes one attempt to acquire or renew leadership and
+	// reports whether this instance holds it
+	TryAcquire(ctx context.Context) (bool, error)
+
+	// Release gives up leadership so a standby can take over right away
+	Release(ctx context.Context) error
+}
+
+// Leader tracks whether this instance is the leader by campaigning with an
+// Elector at a fixed interval
+type Leader struct {
+	elector  Elector
+	interval time.Duration
+	onChange func(leader bool)
+	leader   atomic.Bool
+
+	mu      sync.Mutex
+	term    context.Context // Cancelled when leadership is lost
+	endTerm context.CancelFunc
+}
+
+// New creates a Leader that campaigns every interval. onChange is called on
+// every change of leadership and may be nil.
+func New(elector Elector, interval time.Duration, onChange func(leader bool)) *Leader {
+	term, endTerm := context.WithCancel(context.Background())
+	endTerm()
+	return &Leader{
+		elector:  elector,
+		interval: interval,
+		onChange: onChange,
+		term:     term,
+		endTerm:  endTerm,
+	}
+}
+
+// IsLeader reports whether this instance currently holds leadership
+func (l *Leader) IsLeader() bool {
+	return l.leader.Load()
+}
+
+// Context returns a copy of parent that is cancelled when this instance
+// loses leadership, or at once if it isn't the leader. Work that must only be
+// done by the leader should run under it.
+func (l *Leader) Context(parent context.Context) (context.Context, context.CancelFunc) {
+	l.mu.Lock()
+	term := l.term
+	l.mu.Unlock()
+
+	ctx, cancel := context.WithCancel(parent)
+	if term.Err() != nil {
+		cancel()
+		return ctx, cancel
+	}
+	stop := context.AfterFunc(term, cancel)
+	return ctx, func() {
+		stop()
+		cancel()
+	}
+}
+
+// Campaign makes one attempt to acquire or renew leadership
+func (l *Leader) Campaign(ctx context.Context) {
+	leader, err := l.elector.TryAcquire(ctx)
+	if err != nil {
+		fmt.Printf("Warning: leader election: %v\n", err)
+	}
+	l.set(leader)
+}
+
+// Run campaigns until ctx is cancelled, then releases leadership if held
+func (l *Leader) Run(ctx context.Context) {
+	ticker := time.NewTicker(l.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if l.IsLeader() {
+				releaseCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				if err := l.elector.Release(releaseCtx); err != nil {
+					fmt.Printf("Warning: failed to release leadership: %v\n", err)
+				}
+				cancel()
+				l.set(false)
+			}
+			return
+		case <-ticker.C:
+			l.Campaign(ctx)
+		}
+	}
+}
+
+// set records the leadership state and reports changes
+func (l *Leader) set(leader bool) {
+	// The term changes with leadership, so a caller seeing IsLeader never
+	// gets the context of an earlier term
+	l.mu.Lock()
+	changed := l.leader.Load() != leader
+	if changed {
+		if leader {
+			l.term, l.endTerm = context.WithCancel(context.Background())
+		} else {
+			l.endTerm()
+		}
+		l.leader.Store(leader)
+	}
+	l.mu.Unlock()
+	if !changed {
+		return
+	}
+	if leader {
+		fmt.Println("Acquired leadership, this instance is now active")
+	} else {
+		fmt.Println("Lost leadership, this instance is now on standby")
+	}
+	if l.onChange != nil {
+		l.onChange(leader)
+	}
+}
@@ -0,0 +1,81 @@
+package leader
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+// scriptedElector grants leadership as its leader field says
+type scriptedElector struct {
+	leader bool
+}
+
+func (e *scriptedElector) TryAcquire(ctx context.Context) (bool, error) { return e.leader, nil }
+func (e *scriptedElector) Release(ctx context.Context) error            { return nil }
+
+func TestLeaderContext(t *testing.T) {
+	elector := &scriptedElector{}
+	var changes []bool
+	l := New(elector, time.Hour, func(leader bool) { changes = append(changes, leader) })
+
+	// A standby gets a cancelled context
+	ctx, cancel := l.Context(context.Background())
+	if ctx.Err() == nil {
+		t.Error("context of a standby is not cancelled")
+	}
+	cancel()
+
+	elector.leader = true
+	l.Campaign(context.Background())
+	ctx, cancel = l.Context(context.Background())
+	defer cancel()
+	if ctx.Err() != nil {
+		t.Fatal("context of the leader is cancelled")
+	}
+
+	// Renewing keeps the term
+	l.Campaign(context.Background())
+	if ctx.Err() != nil {
+		t.Fatal("context cancelled by a renewal")
+	}
+
+	// Losing leadership cancels work started under it
+	elector.leader = false
+	l.Campaign(context.Background())
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("context not cancelled after losing leadership")
+	}
+
+	// A new term gets a new context
+	elector.leader = true
+	l.Campaign(context.Background())
+	next, cancelNext := l.Context(context.Background())
+	defer cancelNext()
+	if next.Err() != nil {
+		t.Error("context of the new term is cancelled")
+	}
+
+	if want := []bool{true, false, true}; !slices.Equal(changes, want) {
+		t.Errorf("onChange calls = %v, want %v", changes, want)
+	}
+}
+
+func TestLeaderContextParent(t *testing.T) {
+	l := New(&scriptedElector{leader: true}, time.Hour, nil)
+	l.Campaign(context.Background())
+
+	parent, cancelParent := context.WithCancel(context.Background())
+	ctx, cancel := l.Context(parent)
+	defer cancel()
+	cancelParent()
+	if ctx.Err() == nil {
+		t.Error("context not cancelled with its parent")
+	}
+	if !l.IsLeader() {
+		t.Error("cancelling the parent changed leadership")
+	}
+}
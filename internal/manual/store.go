@@ -0,0 +1,269 @@
+package manual
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/0x4272616E646F6E/unifi-threat-sync/internal/config"
+	"github.com/0x4272616E646F6E/unifi-threat-sync/internal/indicator"
+	"github.com/0x4272616E646F6E/unifi-threat-sync/internal/normalizer"
+)
+
+// ErrNotFound is returned when removing an entry that does not exist
+var ErrNotFound = errors.New("entry not found")
+
+// Entry is a manually blocked IP or CIDR
+type Entry struct {
+	CIDR      string     `json:"cidr"`
+	Reason    string     `json:"reason"`
+	CreatedAt time.Time  `json:"createdAt"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"` // nil = until removed
+}
+
+// Expired reports whether the entry has expired at the given time
+func (e Entry) Expired(now time.Time) bool {
+	return e.ExpiresAt != nil && !now.Before(*e.ExpiresAt)
+}
+
+// Policy limits which entries can be blocked manually. It holds manual
+// entries to the same filter settings as feeds.
+type Policy struct {
+	Reserved bool // Reject entries overlapping a reserved range
+	MinIPv4  int  // Shortest IPv4 prefix accepted (0 = no limit)
+	MinIPv6  int  // Shortest IPv6 prefix accepted (0 = no limit)
+}
+
+// PolicyFrom returns the policy matching the filter settings
+func PolicyFrom(f config.FilterConfig) Policy {
+	return Policy{
+		Reserved: f.FilterReserved(),
+		MinIPv4:  f.Prefix.MinIPv4,
+		MinIPv6:  f.Prefix.MinIPv6,
+	}
+}
+
+// Check returns why prefix may not be blocked, or nil if it may
+func (p Policy) Check(prefix netip.Prefix) error {
+	min := p.MinIPv6
+	if prefix.Addr().Is4() {
+		min = p.MinIPv4
+	}
+	if prefix.Bits() < min {
+		return fmt.Errorf("%s is shorter than the filter.prefix minimum of /%d", prefix, min)
+	}
+	if p.Reserved {
+		if r, ok := normalizer.ReservedOverlap(prefix); ok {
+			return fmt.Errorf("%s overlaps reserved range %s (filter.reserved)", prefix, r)
+		}
+	}
+	return nil
+}
+
+// Store holds manual entries and persists them to a local JSON file
+type Store struct {
+	mu      sync.Mutex
+	path    string
+	entries map[string]Entry
+	policy  Policy
+}
+
+// NewStore creates a store backed by the file at path, loading any entries
+// already saved there
+func NewStore(path string) (*Store, error) {
+	s := &Store{
+		path:    path,
+		entries: make(map[string]Entry),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manual entries: %w", err)
+	}
+
+	var entries []Entry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse manual entries: %w", err)
+	}
+	for _, e := range entries {
+		s.entries[e.CIDR] = e
+	}
+
+	return s, nil
+}
+
+// SetPolicy sets the policy new entries are checked against
+func (s *Store) SetPolicy(p Policy) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.policy = p
+}
+
+// Add blocks an IP or CIDR for ttl (zero = until removed). Adding an existing
+// entry replaces its reason and expiry. Entries the policy doesn't allow are
+// rejected.
+func (s *Store) Add(ipOrCIDR, reason string, ttl time.Duration) (Entry, error) {
+	network, err := parseNetwork(ipOrCIDR)
+	if err != nil {
+		return Entry{}, err
+	}
+	s.mu.Lock()
+	policy := s.policy
+	s.mu.Unlock()
+	if err := policy.Check(network); err != nil {
+		return Entry{}, err
+	}
+	if ttl < 0 {
+		return Entry{}, fmt.Errorf("ttl must not be negative")
+	}
+
+	now := time.Now().UTC()
+	entry := Entry{
+		CIDR:      network.String(),
+		Reason:    reason,
+		CreatedAt: now,
+	}
+	if ttl > 0 {
+		expires := now.Add(ttl)
+		entry.ExpiresAt = &expires
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, existed := s.entries[entry.CIDR]
+	s.entries[entry.CIDR] = entry
+	if err := s.save(); err != nil {
+		// Keep memory in line with the file
+		if existed {
+			s.entries[entry.CIDR] = previous
+		} else {
+			delete(s.entries, entry.CIDR)
+		}
+		return Entry{}, err
+	}
+	return entry, nil
+}
+
+// Remove deletes an entry by IP or CIDR
+func (s *Store) Remove(ipOrCIDR string) error {
+	network, err := parseNetwork(ipOrCIDR)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[network.String()]
+	if !ok {
+		return ErrNotFound
+	}
+	delete(s.entries, network.String())
+	if err := s.save(); err != nil {
+		s.entries[entry.CIDR] = entry
+		return err
+	}
+	return nil
+}
+
+// List returns all unexpired entries sorted by CIDR
+func (s *Store) List() []Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	entries := make([]Entry, 0, len(s.entries))
+	for _, e := range s.entries {
+		if !e.Expired(now) {
+			entries = append(entries, e)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].CIDR < entries[j].CIDR
+	})
+	return entries
+}
+
+// Active returns the prefixes of all unexpired entries and prunes expired
+// entries from the store. The prefixes are valid even when saving the pruned
+// store fails; that error is returned alongside them.
+func (s *Store) Active() ([]netip.Prefix, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	pruned := false
+	prefixes := make([]netip.Prefix, 0, len(s.entries))
+	for key, e := range s.entries {
+		if e.Expired(now) {
+			delete(s.entries, key)
+			pruned = true
+			continue
+		}
+		if prefix, err := parseNetwork(e.CIDR); err == nil {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+
+	if pruned {
+		if err := s.save(); err != nil {
+			return prefixes, err
+		}
+	}
+
+	return prefixes, nil
+}
+
+// save writes all entries to disk atomically; the caller must hold s.mu
+func (s *Store) save() error {
+	entries := make([]Entry, 0, len(s.entries))
+	for _, e := range s.entries {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].CIDR < entries[j].CIDR
+	})
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal manual entries: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".manual-*.json")
+	if err != nil {
+		return fmt.Errorf("failed to save manual entries: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save manual entries: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save manual entries: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to save manual entries: %w", err)
+	}
+
+	return nil
+}
+
+// parseNetwork parses an IP address or CIDR block into its canonical prefix
+func parseNetwork(s string) (netip.Prefix, error) {
+	ind, err := indicator.Parse(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return ind.Prefix, nil
+}
@@ -0,0 +1,72 @@
+package manual
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPolicyCheck(t *testing.T) {
+	strict := Policy{Reserved: true, MinIPv4: 16, MinIPv6: 32}
+	tests := []struct {
+		name   string
+		policy Policy
+		cidr   string
+		want   string // Error substring, empty if allowed
+	}{
+		{"default route", strict, "0.0.0.0/0", "shorter than the filter.prefix minimum of /16"},
+		{"private", strict, "10.0.0.0/8", "shorter than"},
+		{"private at minimum", strict, "10.1.0.0/16", "overlaps reserved range 10.0.0.0/8"},
+		{"inside reserved", strict, "192.168.1.1/32", "overlaps reserved range 192.168.0.0/16"},
+		{"ipv6 too short", strict, "2a00::/16", "minimum of /32"},
+		{"ipv6 reserved", strict, "2001:db8::1/128", "overlaps reserved range 2001:db8::/32"},
+		{"public host", strict, "45.155.205.233/32", ""},
+		{"public range", strict, "45.155.0.0/16", ""},
+		{"public ipv6", strict, "2a06:4880::/32", ""},
+		{"reserved allowed", Policy{MinIPv4: 8}, "10.0.0.0/8", ""},
+		{"no limits", Policy{}, "0.0.0.0/0", ""},
+	}
+	for _, tt := range tests {
+		prefix, err := parseNetwork(tt.cidr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = tt.policy.Check(prefix)
+		switch {
+		case tt.want == "" && err != nil:
+			t.Errorf("%s: Check(%s) error: %v", tt.name, tt.cidr, err)
+		case tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)):
+			t.Errorf("%s: Check(%s) error = %v, want %q", tt.name, tt.cidr, err, tt.want)
+		}
+	}
+}
+
+func TestStoreAddPolicy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "manual.json")
+	s, err := NewStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SetPolicy(Policy{Reserved: true, MinIPv4: 16, MinIPv6: 32})
+
+	for _, cidr := range []string{"0.0.0.0/0", "10.0.0.0/8", "::ffff:10.0.0.1"} {
+		if _, err := s.Add(cidr, "incident", time.Hour); err == nil {
+			t.Errorf("Add(%s) succeeded", cidr)
+		}
+	}
+	if got := s.List(); len(got) != 0 {
+		t.Errorf("rejected entries stored: %v", got)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("store saved after rejected entries: %v", err)
+	}
+
+	if _, err := s.Add("45.155.205.233", "incident", time.Hour); err != nil {
+		t.Fatalf("Add of a public address: %v", err)
+	}
+	if got := s.List(); len(got) != 1 || got[0].CIDR != "45.155.205.233/32" {
+		t.Errorf("List = %v, want 45.155.205.233/32", got)
+	}
+}
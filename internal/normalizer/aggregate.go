@@ -0,0 +1,45 @@
+package normalizer
+
+import "net/netip"
+
+// Aggregate returns the smallest list of CIDRs covering exactly the same
+// addresses as prefixes: prefixes inside larger ones are dropped and sibling
+// prefixes are merged into their parent, repeatedly. IPv4 and IPv6 are
+// aggregated separately and the result is sorted by address, IPv4 first.
+// Prefixes must be canonical, as Normalize returns them; the input is
+// reordered.
+func Aggregate(prefixes []netip.Prefix) []netip.Prefix {
+	sortPrefixes(prefixes)
+
+	// Prefixes are ordered by address and then by length, so a prefix is
+	// covered exactly when the last one kept contains its address. Merged
+	// parents can only pair with the entry below them on the stack.
+	stack := make([]netip.Prefix, 0, len(prefixes))
+	for _, p := range prefixes {
+		if n := len(stack); n > 0 && stack[n-1].Bits() <= p.Bits() && stack[n-1].Contains(p.Addr()) {
+			continue
+		}
+		stack = append(stack, p)
+
+		for n := len(stack); n >= 2; n = len(stack) {
+			parent, ok := siblingParent(stack[n-2], stack[n-1])
+			if !ok {
+				break
+			}
+			stack = append(stack[:n-2], parent)
+		}
+	}
+	return stack
+}
+
+// siblingParent returns the parent of lo and hi if they are its two halves
+func siblingParent(lo, hi netip.Prefix) (netip.Prefix, bool) {
+	if lo.Bits() != hi.Bits() || lo.Bits() == 0 || lo.Addr().BitLen() != hi.Addr().BitLen() {
+		return netip.Prefix{}, false
+	}
+	parent := netip.PrefixFrom(lo.Addr(), lo.Bits()-1).Masked()
+	if parent.Addr() != lo.Addr() || !parent.Contains(hi.Addr()) || lo == hi {
+		return netip.Prefix{}, false
+	}
+	return parent, true
+}
@@ -0,0 +1,104 @@
+package normalizer
+
+import (
+	"math/rand/v2"
+	"net/netip"
+	"slices"
+	"testing"
+)
+
+func TestAggregate(t *testing.T) {
+	tests := []struct {
+		name string
+		in   []string
+		want []string
+	}{
+		{"empty", nil, nil},
+		{"covered dropped", []string{"10.0.0.0/8", "10.1.0.0/16", "10.1.2.3/32"}, []string{"10.0.0.0/8"}},
+		{"siblings merged", []string{"192.0.2.0/25", "192.0.2.128/25"}, []string{"192.0.2.0/24"}},
+		{"merge cascades", []string{"192.0.2.0/26", "192.0.2.64/26", "192.0.2.128/25"}, []string{"192.0.2.0/24"}},
+		{"hosts merged", []string{"192.0.2.0/32", "192.0.2.1/32", "192.0.2.2/32", "192.0.2.3/32"}, []string{"192.0.2.0/30"}},
+		{"adjacent but not siblings", []string{"192.0.2.128/25", "192.0.3.0/25"}, []string{"192.0.2.128/25", "192.0.3.0/25"}},
+		{"unsorted input", []string{"192.0.2.128/25", "10.0.0.0/8", "192.0.2.0/25"}, []string{"10.0.0.0/8", "192.0.2.0/24"}},
+		{"families kept apart", []string{"0.0.0.0/1", "128.0.0.0/1", "::/1", "8000::/1"}, []string{"0.0.0.0/0", "::/0"}},
+		{"ipv6 siblings", []string{"2001:db8::/33", "2001:db8:8000::/33", "2001:db8:1::/48"}, []string{"2001:db8::/32"}},
+	}
+	for _, tt := range tests {
+		got := Aggregate(mustPrefixes(t, tt.in...))
+		if want := mustPrefixes(t, tt.want...); !slices.Equal(got, want) {
+			t.Errorf("%s: Aggregate = %v, want %v", tt.name, got, want)
+		}
+	}
+}
+
+// smallPrefix returns a random prefix inside 10.0.0.0/20 or 2001:db8::/116,
+// small enough to enumerate every covered address
+func smallPrefix(r *rand.Rand) netip.Prefix {
+	if r.IntN(3) == 0 {
+		b := netip.MustParseAddr("2001:db8::").As16()
+		b[14], b[15] = byte(r.IntN(16)), byte(r.IntN(256))
+		return netip.PrefixFrom(netip.AddrFrom16(b), 116+r.IntN(13)).Masked()
+	}
+	return netip.PrefixFrom(netip.AddrFrom4([4]byte{10, 0, byte(r.IntN(16)), byte(r.IntN(256))}), 20+r.IntN(13)).Masked()
+}
+
+// addressSet returns every address covered by prefixes
+func addressSet(prefixes []netip.Prefix) map[netip.Addr]bool {
+	set := make(map[netip.Addr]bool)
+	for _, p := range prefixes {
+		for a := p.Addr(); a.IsValid() && p.Contains(a); a = a.Next() {
+			set[a] = true
+		}
+	}
+	return set
+}
+
+// sameAddresses reports whether two address sets are equal
+func sameAddresses(a, b map[netip.Addr]bool) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for addr := range a {
+		if !b[addr] {
+			return false
+		}
+	}
+	return true
+}
+
+// TestAggregateExactCoverage checks on random lists that Aggregate covers
+// exactly the input addresses with sorted, disjoint prefixes that cannot be
+// merged any further
+func TestAggregateExactCoverage(t *testing.T) {
+	r := rand.New(rand.NewPCG(5, 6))
+
+	for round := 0; round < 200; round++ {
+		in := make([]netip.Prefix, 1+r.IntN(60))
+		for i := range in {
+			in[i] = smallPrefix(r)
+		}
+		want := addressSet(in)
+
+		got := Aggregate(slices.Clone(in))
+		if !sameAddresses(addressSet(got), want) {
+			t.Fatalf("Aggregate(%v) = %v covers different addresses", in, got)
+		}
+
+		if !slices.IsSortedFunc(got, comparePrefix) {
+			t.Fatalf("Aggregate(%v) = %v is not sorted", in, got)
+		}
+		for i := 1; i < len(got); i++ {
+			if got[i-1].Overlaps(got[i]) {
+				t.Fatalf("Aggregate(%v) = %v has overlapping %s and %s", in, got, got[i-1], got[i])
+			}
+			if _, ok := siblingParent(got[i-1], got[i]); ok {
+				t.Fatalf("Aggregate(%v) = %v left siblings %s and %s unmerged", in, got, got[i-1], got[i])
+			}
+		}
+
+		// Aggregating again changes nothing
+		if again := Aggregate(slices.Clone(got)); !slices.Equal(again, got) {
+			t.Fatalf("Aggregate is not idempotent: %v then %v", got, again)
+		}
+	}
+}
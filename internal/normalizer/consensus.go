@@ -0,0 +1,113 @@
+package normalizer
+
+import "net/netip"
+
+// Source is the list of prefixes contributed by one feed
+type Source struct {
+	Name     string
+	Weight   float64
+	Prefixes []netip.Prefix
+}
+
+// Flatten concatenates the prefixes of all sources in order
+func Flatten(sources []Source) []netip.Prefix {
+	capacity := 0
+	for _, src := range sources {
+		capacity += len(src.Prefixes)
+	}
+
+	result := make([]netip.Prefix, 0, capacity)
+	for _, src := range sources {
+		result = append(result, src.Prefixes...)
+	}
+	return result
+}
+
+// weightedSet is a source indexed for coverage lookups
+type weightedSet struct {
+	weight   float64
+	prefixes map[netip.Prefix]struct{}
+	lengths4 []int // distinct IPv4 prefix lengths present
+	lengths6 []int // distinct IPv6 prefix lengths present
+}
+
+// covers reports whether the set holds p or a prefix containing p
+func (ws *weightedSet) covers(p netip.Prefix) bool {
+	lengths := ws.lengths6
+	if p.Addr().Is4() {
+		lengths = ws.lengths4
+	}
+	for _, l := range lengths {
+		if l > p.Bits() {
+			continue
+		}
+		if _, ok := ws.prefixes[netip.PrefixFrom(p.Addr(), l).Masked()]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Consensus scores every listed prefix by summing the weights of the sources
+// that list it or a prefix covering it, and keeps only prefixes whose score
+// reaches threshold. The result is normalized.
+func Consensus(sources []Source, threshold float64) []netip.Prefix {
+	// When any single source is enough, scoring reduces to a plain union
+	minWeight := 0.0
+	for i, src := range sources {
+		if i == 0 || src.Weight < minWeight {
+			minWeight = src.Weight
+		}
+	}
+	if threshold <= minWeight {
+		return Normalize(Flatten(sources))
+	}
+
+	sets := make([]*weightedSet, 0, len(sources))
+	for _, src := range sources {
+		ws := &weightedSet{
+			weight:   src.Weight,
+			prefixes: make(map[netip.Prefix]struct{}, len(src.Prefixes)),
+		}
+		seen4 := make(map[int]bool)
+		seen6 := make(map[int]bool)
+		for _, p := range src.Prefixes {
+			p, ok := canonical(p)
+			if !ok {
+				continue
+			}
+			ws.prefixes[p] = struct{}{}
+			if p.Addr().Is4() && !seen4[p.Bits()] {
+				seen4[p.Bits()] = true
+				ws.lengths4 = append(ws.lengths4, p.Bits())
+			} else if p.Addr().Is6() && !seen6[p.Bits()] {
+				seen6[p.Bits()] = true
+				ws.lengths6 = append(ws.lengths6, p.Bits())
+			}
+		}
+		sets = append(sets, ws)
+	}
+
+	scored := make(map[netip.Prefix]bool)
+	var result []netip.Prefix
+	for _, ws := range sets {
+		for p := range ws.prefixes {
+			if scored[p] {
+				continue
+			}
+			scored[p] = true
+
+			score := 0.0
+			for _, other := range sets {
+				if other.covers(p) {
+					score += other.weight
+				}
+			}
+			if score >= threshold {
+				result = append(result, p)
+			}
+		}
+	}
+
+	return Normalize(result)
+}
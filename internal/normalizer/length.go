@@ -0,0 +1,65 @@
+package normalizer
+
+import "net/netip"
+
+// LengthPolicy sets the shortest prefix length accepted per address family
+type LengthPolicy struct {
+	MinIPv4  int  // 0 = no limit
+	MinIPv6  int  // 0 = no limit
+	Split    bool // Split prefixes that are too short instead of rejecting them
+	MaxSplit int  // Most pieces a prefix is split into; broader ones are rejected
+}
+
+// LengthViolation records a prefix shorter than the policy allows
+type LengthViolation struct {
+	Prefix netip.Prefix
+	Pieces int // Prefixes it was split into, 0 if it was rejected
+}
+
+// ApplyLengthPolicy drops prefixes shorter than the minimum length for their
+// address family, or with policy.Split replaces them by the prefixes of the
+// minimum length covering them. Every prefix that violated the policy is
+// reported.
+func ApplyLengthPolicy(prefixes []netip.Prefix, policy LengthPolicy) ([]netip.Prefix, []LengthViolation) {
+	result := make([]netip.Prefix, 0, len(prefixes))
+	var violations []LengthViolation
+	for _, p := range prefixes {
+		min := policy.MinIPv6
+		if p.Addr().Is4() {
+			min = policy.MinIPv4
+		}
+		if p.Bits() >= min {
+			result = append(result, p)
+			continue
+		}
+
+		v := LengthViolation{Prefix: p}
+		if policy.Split {
+			if pieces, ok := splitTo(p, min, policy.MaxSplit); ok {
+				result = append(result, pieces...)
+				v.Pieces = len(pieces)
+			}
+		}
+		violations = append(violations, v)
+	}
+	return result, violations
+}
+
+// splitTo splits p into the prefixes of length bits covering it, unless that
+// takes more than max pieces
+func splitTo(p netip.Prefix, bits, max int) ([]netip.Prefix, bool) {
+	if extra := bits - p.Bits(); extra >= 31 || 1<<extra > max {
+		return nil, false
+	}
+
+	pieces := []netip.Prefix{p.Masked()}
+	for pieces[0].Bits() < bits {
+		next := make([]netip.Prefix, 0, 2*len(pieces))
+		for _, q := range pieces {
+			lo, hi := splitPrefix(q)
+			next = append(next, lo, hi)
+		}
+		pieces = next
+	}
+	return pieces, true
+}
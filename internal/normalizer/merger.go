@@ -1,9 +1,9 @@
 package normalizer
 
-import "net"
+import "net/netip"
 
-// Merge combines multiple lists of IP networks and normalizes them
-func Merge(lists ...[]net.IPNet) []net.IPNet {
+// Merge combines multiple lists of prefixes and normalizes them
+func Merge(lists ...[]netip.Prefix) []netip.Prefix {
 	// Calculate total capacity
 	capacity := 0
 	for _, list := range lists {
@@ -11,7 +11,7 @@ func Merge(lists ...[]net.IPNet) []net.IPNet {
 	}
 
 	// Merge all lists
-	merged := make([]net.IPNet, 0, capacity)
+	merged := make([]netip.Prefix, 0, capacity)
 	for _, list := range lists {
 		merged = append(merged, list...)
 	}
@@ -1,77 +1,84 @@
 package normalizer
 
 import (
-	"net"
-	"sort"
+	"cmp"
+	"net/netip"
+	"slices"
+
+	"github.com/0x4272616E646F6E/unifi-threat-sync/internal/indicator"
 )
 
-// Normalize takes a list of IP networks and deduplicates them
-func Normalize(networks []net.IPNet) []net.IPNet {
-	if len(networks) == 0 {
-		return networks
+// Normalize masks, deduplicates and sorts prefixes. Invalid prefixes are
+// dropped and IPv4-mapped IPv6 prefixes are turned into the IPv4 prefixes
+// they carry.
+func Normalize(prefixes []netip.Prefix) []netip.Prefix {
+	if len(prefixes) == 0 {
+		return prefixes
 	}
 
-	// Use map for deduplication
-	seen := make(map[string]net.IPNet)
-	for _, network := range networks {
-		key := network.String()
-		seen[key] = network
+	result := make([]netip.Prefix, 0, len(prefixes))
+	for _, p := range prefixes {
+		if p, ok := canonical(p); ok {
+			result = append(result, p)
+		}
 	}
 
-	// Convert back to slice
-	result := make([]net.IPNet, 0, len(seen))
-	for _, network := range seen {
-		result = append(result, network)
-	}
+	// Sort numerically so duplicates are adjacent and output is stable
+	sortPrefixes(result)
+	return slices.Compact(result)
+}
 
-	// Sort for consistent output
-	sort.Slice(result, func(i, j int) bool {
-		return result[i].String() < result[j].String()
-	})
+// Contains checks if a prefix is in the list; use a Trie for repeated queries
+func Contains(prefixes []netip.Prefix, target netip.Prefix) bool {
+	return slices.Contains(prefixes, target)
+}
 
+// ToStrings converts a prefix slice to a string slice
+func ToStrings(prefixes []netip.Prefix) []string {
+	result := make([]string, len(prefixes))
+	for i, p := range prefixes {
+		result[i] = p.String()
+	}
 	return result
 }
 
-// Contains checks if a network is in the list
-func Contains(networks []net.IPNet, target net.IPNet) bool {
-	targetStr := target.String()
-	for _, network := range networks {
-		if network.String() == targetStr {
-			return true
+// FromStrings converts IP addresses and CIDRs to prefixes, skipping entries
+// that don't parse
+func FromStrings(strs []string) ([]netip.Prefix, error) {
+	result := make([]netip.Prefix, 0, len(strs))
+	for _, s := range strs {
+		if ind, err := indicator.Parse(s); err == nil {
+			result = append(result, ind.Prefix)
 		}
 	}
-	return false
+	return result, nil
 }
 
-// ToStrings converts IPNet slice to string slice
-func ToStrings(networks []net.IPNet) []string {
-	result := make([]string, len(networks))
-	for i, network := range networks {
-		result[i] = network.String()
+// canonical masks p and turns an IPv4-mapped IPv6 prefix into the IPv4
+// prefix it carries
+func canonical(p netip.Prefix) (netip.Prefix, bool) {
+	if !p.IsValid() {
+		return netip.Prefix{}, false
 	}
-	return result
+	if addr := p.Addr(); addr.Is4In6() {
+		bits := p.Bits() - 96
+		if bits < 0 {
+			return netip.Prefix{}, false
+		}
+		p = netip.PrefixFrom(addr.Unmap(), bits)
+	}
+	return p.Masked(), true
 }
 
-// FromStrings converts string slice to IPNet slice
-func FromStrings(strs []string) ([]net.IPNet, error) {
-	result := make([]net.IPNet, 0, len(strs))
-	for _, s := range strs {
-		_, ipnet, err := net.ParseCIDR(s)
-		if err != nil {
-			// Try as single IP
-			ip := net.ParseIP(s)
-			if ip == nil {
-				continue
-			}
-			var mask net.IPMask
-			if ip.To4() != nil {
-				mask = net.CIDRMask(32, 32)
-			} else {
-				mask = net.CIDRMask(128, 128)
-			}
-			ipnet = &net.IPNet{IP: ip, Mask: mask}
-		}
-		result = append(result, *ipnet)
+// comparePrefix orders prefixes by address, then by length, IPv4 first
+func comparePrefix(a, b netip.Prefix) int {
+	if c := a.Addr().Compare(b.Addr()); c != 0 {
+		return c
 	}
-	return result, nil
+	return cmp.Compare(a.Bits(), b.Bits())
+}
+
+// sortPrefixes sorts prefixes with comparePrefix
+func sortPrefixes(prefixes []netip.Prefix) {
+	slices.SortFunc(prefixes, comparePrefix)
 }
@@ -1 +1,93 @@
 package normalizer
+
+import (
+	"encoding/binary"
+	"math/rand/v2"
+	"net"
+	"net/netip"
+	"sort"
+	"testing"
+)
+
+// benchFeedSize is the number of entries in the synthetic benchmark feed
+const benchFeedSize = 1_000_000
+
+// syntheticFeed returns n pseudo-random prefixes shaped like a large feed:
+// mostly IPv4 hosts, some IPv4 ranges and a share of IPv6, with duplicates
+func syntheticFeed(n int) []netip.Prefix {
+	r := rand.New(rand.NewPCG(1, 2))
+	prefixes := make([]netip.Prefix, n)
+	for i := range prefixes {
+		switch k := r.IntN(10); {
+		case k < 7:
+			var b [4]byte
+			binary.BigEndian.PutUint32(b[:], r.Uint32())
+			prefixes[i] = netip.PrefixFrom(netip.AddrFrom4(b), 32)
+		case k < 9:
+			var b [4]byte
+			binary.BigEndian.PutUint32(b[:], r.Uint32())
+			prefixes[i] = netip.PrefixFrom(netip.AddrFrom4(b), 16+r.IntN(16)).Masked()
+		default:
+			var b [16]byte
+			b[0], b[1] = 0x20, 0x01
+			binary.BigEndian.PutUint32(b[2:6], r.Uint32())
+			binary.BigEndian.PutUint32(b[6:10], r.Uint32())
+			prefixes[i] = netip.PrefixFrom(netip.AddrFrom16(b), 48+r.IntN(81)).Masked()
+		}
+		if i > 0 && r.IntN(20) == 0 {
+			prefixes[i] = prefixes[r.IntN(i)]
+		}
+	}
+	return prefixes
+}
+
+// normalizeByString is the previous string-keyed Normalize, kept as a
+// baseline for BenchmarkNormalize
+func normalizeByString(networks []net.IPNet) []net.IPNet {
+	seen := make(map[string]net.IPNet)
+	for _, network := range networks {
+		seen[network.String()] = network
+	}
+	result := make([]net.IPNet, 0, len(seen))
+	for _, network := range seen {
+		result = append(result, network)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].String() < result[j].String()
+	})
+	return result
+}
+
+func BenchmarkNormalize(b *testing.B) {
+	feed := syntheticFeed(benchFeedSize)
+	for b.Loop() {
+		Normalize(feed)
+	}
+}
+
+func BenchmarkNormalizeByString(b *testing.B) {
+	feed := syntheticFeed(benchFeedSize)
+	networks := make([]net.IPNet, len(feed))
+	for i, p := range feed {
+		networks[i] = net.IPNet{IP: p.Addr().AsSlice(), Mask: net.CIDRMask(p.Bits(), p.Addr().BitLen())}
+	}
+	for b.Loop() {
+		normalizeByString(networks)
+	}
+}
+
+func BenchmarkAggregate(b *testing.B) {
+	feed := Normalize(syntheticFeed(benchFeedSize))
+	prefixes := make([]netip.Prefix, len(feed))
+	for b.Loop() {
+		copy(prefixes, feed)
+		Aggregate(prefixes)
+	}
+}
+
+func BenchmarkFromStrings(b *testing.B) {
+	strs := ToStrings(syntheticFeed(benchFeedSize))
+	for b.Loop() {
+		FromStrings(strs)
+	}
+}
@@ -0,0 +1,106 @@
+package normalizer
+
+import (
+	"net/netip"
+	"slices"
+	"sort"
+)
+
+// Entry is a normalized prefix together with the sources that listed it
+type Entry struct {
+	Prefix  netip.Prefix `json:"-"`
+	CIDR    string       `json:"cidr"`
+	Sources []string     `json:"sources"`
+}
+
+// NormalizeEntries deduplicates the prefixes of all sources like Normalize,
+// but keeps for every prefix the sorted set of source names that listed it
+func NormalizeEntries(sources []Source) []Entry {
+	bySource := make(map[netip.Prefix]map[string]bool)
+	for _, src := range sources {
+		for _, p := range src.Prefixes {
+			p, ok := canonical(p)
+			if !ok {
+				continue
+			}
+			if bySource[p] == nil {
+				bySource[p] = make(map[string]bool)
+			}
+			bySource[p][src.Name] = true
+		}
+	}
+
+	entries := make([]Entry, 0, len(bySource))
+	for p, set := range bySource {
+		names := make([]string, 0, len(set))
+		for name := range set {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		entries = append(entries, Entry{Prefix: p, CIDR: p.String(), Sources: names})
+	}
+
+	slices.SortFunc(entries, func(a, b Entry) int {
+		return comparePrefix(a.Prefix, b.Prefix)
+	})
+	return entries
+}
+
+// Index answers which sources listed a prefix, either exactly, as part of a
+// larger prefix covering it or as smaller prefixes inside it (which
+// aggregation may have merged into it)
+type Index struct {
+	sources Trie[[]string]
+}
+
+// NewIndex builds an index from normalized entries
+func NewIndex(entries []Entry) *Index {
+	ix := &Index{}
+	for _, e := range entries {
+		names, _ := ix.sources.Get(e.Prefix)
+		ix.sources.Insert(e.Prefix, mergeNames(names, e.Sources))
+	}
+	return ix
+}
+
+// Sources returns the sorted names of all sources that listed p, a prefix
+// covering it or a prefix inside it
+func (ix *Index) Sources(p netip.Prefix) []string {
+	var names []string
+	for _, sources := range ix.sources.Covering(p) {
+		names = mergeNames(names, sources)
+	}
+	for _, sources := range ix.sources.CoveredBy(p) {
+		names = mergeNames(names, sources)
+	}
+	return names
+}
+
+// mergeNames returns the sorted union of two sorted name lists
+func mergeNames(a, b []string) []string {
+	if len(b) == 0 {
+		return a
+	}
+	if len(a) == 0 {
+		return append([]string(nil), b...)
+	}
+
+	result := make([]string, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] < b[j]:
+			result = append(result, a[i])
+			i++
+		case a[i] > b[j]:
+			result = append(result, b[j])
+			j++
+		default:
+			result = append(result, a[i])
+			i++
+			j++
+		}
+	}
+	result = append(result, a[i:]...)
+	return append(result, b[j:]...)
+}
@@ -0,0 +1,79 @@
+package normalizer
+
+import (
+	"net/netip"
+	"sync"
+)
+
+// reservedCIDRs lists IANA special-purpose ranges that are not globally
+// routable and must never end up in a blocklist (RFC 6890 and successors).
+// IPv4-mapped IPv6 (::ffff:0:0/96) is left out on purpose: mapped entries are
+// treated as the IPv4 addresses they carry.
+var reservedCIDRs = []string{
+	// IPv4
+	"0.0.0.0/8",       // "This network"
+	"10.0.0.0/8",      // Private-use
+	"100.64.0.0/10",   // Shared address space (CGNAT)
+	"127.0.0.0/8",     // Loopback
+	"169.254.0.0/16",  // Link-local
+	"172.16.0.0/12",   // Private-use
+	"192.0.0.0/24",    // IETF protocol assignments
+	"192.0.2.0/24",    // Documentation (TEST-NET-1)
+	"192.88.99.0/24",  // Deprecated 6to4 relay anycast
+	"192.168.0.0/16",  // Private-use
+	"198.18.0.0/15",   // Benchmarking
+	"198.51.100.0/24", // Documentation (TEST-NET-2)
+	"203.0.113.0/24",  // Documentation (TEST-NET-3)
+	"224.0.0.0/4",     // Multicast
+	"240.0.0.0/4",     // Reserved, includes limited broadcast
+	// IPv6
+	"::/128",         // Unspecified
+	"::1/128",        // Loopback
+	"64:ff9b:1::/48", // Local-use IPv4/IPv6 translation
+	"100::/64",       // Discard-only
+	"2001:2::/48",    // Benchmarking
+	"2001:db8::/32",  // Documentation
+	"3fff::/20",      // Documentation
+	"fc00::/7",       // Unique local
+	"fe80::/10",      // Link-local unicast
+	"ff00::/8",       // Multicast
+}
+
+var (
+	reservedOnce     sync.Once
+	reservedPrefixes []netip.Prefix
+)
+
+// Reserved returns the special-purpose ranges filtered by FilterReserved
+func Reserved() []netip.Prefix {
+	reservedOnce.Do(func() {
+		reservedPrefixes, _ = FromStrings(reservedCIDRs)
+	})
+	return reservedPrefixes
+}
+
+// ReservedOverlap returns a special-purpose range that p overlaps, if any
+func ReservedOverlap(p netip.Prefix) (netip.Prefix, bool) {
+	for _, r := range Reserved() {
+		if r.Overlaps(p) {
+			return r, true
+		}
+	}
+	return netip.Prefix{}, false
+}
+
+// FilterReserved removes special-purpose ranges (private, CGNAT, loopback,
+// multicast, documentation, ...) from prefixes. Entries that only partially
+// overlap a reserved range are split so the public part is kept. It returns
+// the remaining prefixes and the number of input entries that were affected.
+func FilterReserved(prefixes []netip.Prefix) ([]netip.Prefix, int) {
+	kept, collisions := Subtract(prefixes, Reserved())
+
+	// Count distinct input entries, an entry can overlap several ranges
+	affected := make(map[netip.Prefix]bool, len(collisions))
+	for _, c := range collisions {
+		affected[c.Blocked] = true
+	}
+
+	return kept, len(affected)
+}
@@ -0,0 +1,43 @@
+package normalizer
+
+import (
+	"math/big"
+	"net/netip"
+)
+
+// Union returns the addresses in a or b as the smallest list of CIDRs
+func Union(a, b []netip.Prefix) []netip.Prefix {
+	return Aggregate(Merge(a, b))
+}
+
+// Intersect returns the addresses in both a and b as the smallest list of
+// CIDRs. The lists may use different prefix lengths for the same ranges.
+func Intersect(a, b []netip.Prefix) []netip.Prefix {
+	other := NewTrie[struct{}](b)
+
+	// Two overlapping prefixes share exactly the longer one
+	var result []netip.Prefix
+	for _, p := range Normalize(a) {
+		if _, _, ok := other.LongestMatch(p); ok {
+			result = append(result, p)
+			continue
+		}
+		for q := range other.CoveredBy(p) {
+			result = append(result, q)
+		}
+	}
+	return Aggregate(result)
+}
+
+// Difference returns the addresses in a but not in b as the smallest list of
+// CIDRs. Prefixes of a that b covers only in part are split.
+func Difference(a, b []netip.Prefix) []netip.Prefix {
+	result, _ := Subtract(Normalize(a), b)
+	return Aggregate(result)
+}
+
+// AddressCount returns the number of distinct addresses covered by prefixes,
+// IPv4 and IPv6 together. Overlapping prefixes are counted once.
+func AddressCount(prefixes []netip.Prefix) *big.Int {
+	return addressCount(Aggregate(Normalize(prefixes)))
+}
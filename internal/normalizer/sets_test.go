@@ -0,0 +1,156 @@
+package normalizer
+
+import (
+	"math/big"
+	"math/rand/v2"
+	"net/netip"
+	"slices"
+	"testing"
+)
+
+func TestSetOperations(t *testing.T) {
+	tests := []struct {
+		name      string
+		a, b      []string
+		union     []string
+		intersect []string
+		diff      []string
+	}{
+		{
+			name:  "empty",
+			union: nil, intersect: nil, diff: nil,
+		},
+		{
+			name:      "disjoint",
+			a:         []string{"192.0.2.0/24"},
+			b:         []string{"198.51.100.0/24"},
+			union:     []string{"192.0.2.0/24", "198.51.100.0/24"},
+			intersect: nil,
+			diff:      []string{"192.0.2.0/24"},
+		},
+		{
+			name:      "a covers b",
+			a:         []string{"10.0.0.0/8"},
+			b:         []string{"10.1.0.0/16"},
+			union:     []string{"10.0.0.0/8"},
+			intersect: []string{"10.1.0.0/16"},
+			diff:      []string{"10.0.0.0/16", "10.2.0.0/15", "10.4.0.0/14", "10.8.0.0/13", "10.16.0.0/12", "10.32.0.0/11", "10.64.0.0/10", "10.128.0.0/9"},
+		},
+		{
+			name:      "b covers a",
+			a:         []string{"10.1.2.0/24", "10.1.3.0/24"},
+			b:         []string{"10.1.0.0/16"},
+			union:     []string{"10.1.0.0/16"},
+			intersect: []string{"10.1.2.0/23"},
+			diff:      nil,
+		},
+		{
+			name:      "same range, different lengths",
+			a:         []string{"192.0.2.0/24"},
+			b:         []string{"192.0.2.0/25", "192.0.2.128/25"},
+			union:     []string{"192.0.2.0/24"},
+			intersect: []string{"192.0.2.0/24"},
+			diff:      nil,
+		},
+		{
+			name:      "mixed families",
+			a:         []string{"192.0.2.0/24", "2001:db8::/32"},
+			b:         []string{"192.0.2.1/32", "2001:db8:1::/48", "2001:db9::/32"},
+			union:     []string{"192.0.2.0/24", "2001:db8::/31"},
+			intersect: []string{"192.0.2.1/32", "2001:db8:1::/48"},
+			diff: []string{
+				"192.0.2.0/32", "192.0.2.2/31", "192.0.2.4/30", "192.0.2.8/29", "192.0.2.16/28",
+				"192.0.2.32/27", "192.0.2.64/26", "192.0.2.128/25",
+				"2001:db8::/48", "2001:db8:2::/47", "2001:db8:4::/46", "2001:db8:8::/45", "2001:db8:10::/44",
+				"2001:db8:20::/43", "2001:db8:40::/42", "2001:db8:80::/41", "2001:db8:100::/40",
+				"2001:db8:200::/39", "2001:db8:400::/38", "2001:db8:800::/37", "2001:db8:1000::/36",
+				"2001:db8:2000::/35", "2001:db8:4000::/34", "2001:db8:8000::/33",
+			},
+		},
+	}
+	for _, tt := range tests {
+		a, b := mustPrefixes(t, tt.a...), mustPrefixes(t, tt.b...)
+		if got, want := Union(a, b), mustPrefixes(t, tt.union...); !slices.Equal(got, want) {
+			t.Errorf("%s: Union = %v, want %v", tt.name, got, want)
+		}
+		if got, want := Intersect(a, b), mustPrefixes(t, tt.intersect...); !slices.Equal(got, want) {
+			t.Errorf("%s: Intersect = %v, want %v", tt.name, got, want)
+		}
+		if got, want := Difference(a, b), mustPrefixes(t, tt.diff...); !slices.Equal(got, want) {
+			t.Errorf("%s: Difference = %v, want %v", tt.name, got, want)
+		}
+	}
+}
+
+func TestAddressCount(t *testing.T) {
+	tests := []struct {
+		in   []string
+		want string
+	}{
+		{nil, "0"},
+		{[]string{"192.0.2.1/32"}, "1"},
+		{[]string{"192.0.2.0/24", "192.0.2.0/25", "192.0.2.7/32"}, "256"},
+		{[]string{"192.0.2.0/24", "198.51.100.0/24"}, "512"},
+		{[]string{"0.0.0.0/0"}, "4294967296"},
+		{[]string{"::/0"}, "340282366920938463463374607431768211456"},
+		{[]string{"0.0.0.0/0", "::/0"}, "340282366920938463463374607436063178752"},
+		{[]string{"2001:db8::/64", "10.0.0.0/8"}, "18446744073726328832"},
+	}
+	for _, tt := range tests {
+		want, _ := new(big.Int).SetString(tt.want, 10)
+		if got := AddressCount(mustPrefixes(t, tt.in...)); got.Cmp(want) != 0 {
+			t.Errorf("AddressCount(%v) = %s, want %s", tt.in, got, want)
+		}
+	}
+}
+
+// TestSetOperationsMatchAddresses checks the set operations address by
+// address on random lists
+func TestSetOperationsMatchAddresses(t *testing.T) {
+	r := rand.New(rand.NewPCG(9, 10))
+
+	randomList := func() []netip.Prefix {
+		list := make([]netip.Prefix, r.IntN(20))
+		for i := range list {
+			list[i] = smallPrefix(r)
+		}
+		return list
+	}
+
+	for round := 0; round < 200; round++ {
+		a, b := randomList(), randomList()
+		setA, setB := addressSet(a), addressSet(b)
+
+		union := make(map[netip.Addr]bool)
+		intersect := make(map[netip.Addr]bool)
+		diff := make(map[netip.Addr]bool)
+		for addr := range setA {
+			union[addr] = true
+			if setB[addr] {
+				intersect[addr] = true
+			} else {
+				diff[addr] = true
+			}
+		}
+		for addr := range setB {
+			union[addr] = true
+		}
+
+		check := func(op string, got []netip.Prefix, want map[netip.Addr]bool) {
+			t.Helper()
+			if !sameAddresses(addressSet(got), want) {
+				t.Fatalf("round %d: %s(%v, %v) = %v covers the wrong addresses", round, op, a, b, got)
+			}
+			if again := Aggregate(slices.Clone(got)); !slices.Equal(again, got) {
+				t.Fatalf("round %d: %s result %v is not aggregated", round, op, got)
+			}
+		}
+		check("Union", Union(a, b), union)
+		check("Intersect", Intersect(a, b), intersect)
+		check("Difference", Difference(a, b), diff)
+
+		if got := AddressCount(a); got.Cmp(big.NewInt(int64(len(setA)))) != 0 {
+			t.Fatalf("round %d: AddressCount(%v) = %s, want %d", round, a, got, len(setA))
+		}
+	}
+}
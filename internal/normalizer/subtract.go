@@ -0,0 +1,82 @@
+package normalizer
+
+import "net/netip"
+
+// Collision records an allowlist entry that removed (part of) a blocked prefix
+type Collision struct {
+	Blocked netip.Prefix // Prefix from the blocklist
+	Allowed netip.Prefix // Allowlist entry that overlapped it
+}
+
+// Subtract removes every address covered by allow from prefixes. Blocked
+// prefixes that only partially overlap an allowlist entry are split into the
+// largest CIDRs that cover the remaining space. The result is normalized and
+// every overlap is reported as a Collision.
+func Subtract(prefixes, allow []netip.Prefix) ([]netip.Prefix, []Collision) {
+	if len(allow) == 0 {
+		return prefixes, nil
+	}
+
+	allowed := NewTrie[struct{}](allow)
+
+	var result []netip.Prefix
+	var collisions []Collision
+	for _, blocked := range prefixes {
+		pieces := []netip.Prefix{blocked}
+		remove := func(a netip.Prefix) {
+			collisions = append(collisions, Collision{Blocked: blocked, Allowed: a})
+
+			var remaining []netip.Prefix
+			for _, piece := range pieces {
+				remaining = append(remaining, subtractPrefix(piece, a)...)
+			}
+			pieces = remaining
+		}
+
+		// Only allowlist entries covering or inside the blocked prefix overlap it
+		for a := range allowed.Covering(blocked) {
+			remove(a)
+		}
+		for a := range allowed.CoveredBy(blocked) {
+			if a != blocked {
+				remove(a)
+			}
+		}
+		result = append(result, pieces...)
+	}
+
+	return Normalize(result), collisions
+}
+
+// subtractPrefix returns the CIDRs covering p minus a
+func subtractPrefix(p, a netip.Prefix) []netip.Prefix {
+	if !p.Overlaps(a) {
+		return []netip.Prefix{p}
+	}
+	if a.Bits() <= p.Bits() {
+		// a covers all of p
+		return nil
+	}
+
+	// p strictly contains a: split p in half and keep the half without a,
+	// then recurse into the half that holds it
+	lo, hi := splitPrefix(p)
+	if lo.Overlaps(a) {
+		return append([]netip.Prefix{hi}, subtractPrefix(lo, a)...)
+	}
+	return append([]netip.Prefix{lo}, subtractPrefix(hi, a)...)
+}
+
+// splitPrefix splits p into its two child prefixes one bit longer
+func splitPrefix(p netip.Prefix) (netip.Prefix, netip.Prefix) {
+	bits := p.Bits() + 1
+	lo := netip.PrefixFrom(p.Addr(), bits)
+
+	b := p.Addr().AsSlice()
+	idx := p.Bits() / 8
+	b[idx] |= 0x80 >> (p.Bits() % 8)
+	addr, _ := netip.AddrFromSlice(b)
+	hi := netip.PrefixFrom(addr, bits)
+
+	return lo, hi
+}
@@ -0,0 +1,236 @@
+package normalizer
+
+import (
+	"container/heap"
+	"math"
+	"math/big"
+	"net/netip"
+	"slices"
+)
+
+// Summary reports what Summarize did
+type Summary struct {
+	Before         int      // Prefixes after exact aggregation
+	After          int      // Prefixes returned
+	Merges         int      // Supernets created
+	ExtraAddresses *big.Int // Addresses blocked that no input prefix covered
+	TargetReached  bool     // Whether After is at most the target
+}
+
+// Summarize reduces networks to at most target prefixes by greedily
+// replacing neighbouring prefixes with their smallest covering supernet,
+// always taking the merge that blocks the fewest extra addresses. Supernets
+// never overlap avoid (the allowlist and, when filtered, the reserved ranges)
+// or are shorter than the minimum lengths of policy, and no input prefix is
+// widened by more than maxWiden bits, so the target may not be reachable.
+// The input is aggregated first; IPv4 and IPv6 are never merged with each
+// other.
+func Summarize(prefixes, avoid []netip.Prefix, policy LengthPolicy, target, maxWiden int) ([]netip.Prefix, Summary) {
+	prefixes = Aggregate(slices.Clone(prefixes))
+	s := &summarizer{
+		avoid:    NewTrie[struct{}](avoid),
+		policy:   policy,
+		maxWiden: maxWiden,
+	}
+
+	summary := Summary{Before: len(prefixes)}
+	before := addressCount(prefixes)
+	result := s.run(prefixes, target)
+	summary.ExtraAddresses = new(big.Int).Sub(addressCount(result), before)
+	summary.Merges = s.merges
+	summary.After = len(result)
+	summary.TargetReached = summary.After <= target
+	return result, summary
+}
+
+// sumNode is a prefix in the ordered list being summarized
+type sumNode struct {
+	prefix     netip.Prefix
+	longest    int // Longest input prefix length inside prefix
+	prev, next *sumNode
+	removed    bool
+	version    int // Bumped whenever prefix changes
+}
+
+// mergeCandidate is a possible merge of a node and its successor
+type mergeCandidate struct {
+	left, right  *sumNode
+	leftVersion  int
+	rightVersion int
+	supernet     netip.Prefix
+	extra        float64 // Addresses the merge adds
+}
+
+// candidateHeap orders candidates by extra addresses, then by address
+type candidateHeap []*mergeCandidate
+
+func (h candidateHeap) Len() int { return len(h) }
+func (h candidateHeap) Less(i, j int) bool {
+	if h[i].extra != h[j].extra {
+		return h[i].extra < h[j].extra
+	}
+	return h[i].supernet.Addr().Less(h[j].supernet.Addr())
+}
+func (h candidateHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *candidateHeap) Push(x any)   { *h = append(*h, x.(*mergeCandidate)) }
+func (h *candidateHeap) Pop() any {
+	old := *h
+	c := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return c
+}
+
+// summarizer holds the state of one Summarize call
+type summarizer struct {
+	avoid    *Trie[struct{}]
+	policy   LengthPolicy
+	maxWiden int
+	heap     candidateHeap
+	merges   int
+}
+
+// run merges the sorted, disjoint prefixes until at most target remain or
+// no allowed merge is left
+func (s *summarizer) run(prefixes []netip.Prefix, target int) []netip.Prefix {
+	if len(prefixes) <= target {
+		return prefixes
+	}
+
+	var head, tail *sumNode
+	for _, p := range prefixes {
+		n := &sumNode{prefix: p, longest: p.Bits(), prev: tail}
+		if tail == nil {
+			head = n
+		} else {
+			tail.next = n
+		}
+		tail = n
+	}
+	for n := head; n != nil && n.next != nil; n = n.next {
+		s.consider(n)
+	}
+
+	count := len(prefixes)
+	for count > target && len(s.heap) > 0 {
+		c := heap.Pop(&s.heap).(*mergeCandidate)
+		if c.left.removed || c.left.next != c.right ||
+			c.left.version != c.leftVersion || c.right.version != c.rightVersion {
+			continue
+		}
+
+		// Fold every node inside the supernet into the left node
+		n := c.left
+		for n.prev != nil && c.supernet.Contains(n.prev.prefix.Addr()) {
+			n = n.prev
+		}
+		merged := n
+		for n = merged.next; n != nil && c.supernet.Contains(n.prefix.Addr()); n = n.next {
+			merged.longest = max(merged.longest, n.longest)
+			n.removed = true
+			count--
+		}
+		merged.next = n
+		if n != nil {
+			n.prev = merged
+		}
+		if merged.prev == nil {
+			head = merged
+		}
+		merged.prefix = c.supernet
+		merged.version++
+		s.merges++
+
+		if merged.prev != nil {
+			s.consider(merged.prev)
+		}
+		if merged.next != nil {
+			s.consider(merged)
+		}
+	}
+
+	result := make([]netip.Prefix, 0, count)
+	for n := head; n != nil; n = n.next {
+		result = append(result, n.prefix)
+	}
+	return result
+}
+
+// consider queues the merge of n with its successor if it is allowed
+func (s *summarizer) consider(n *sumNode) {
+	next := n.next
+	if n.prefix.Addr().Is4() != next.prefix.Addr().Is4() {
+		return
+	}
+
+	supernet := commonSupernet(n.prefix, next.prefix)
+	minBits := s.policy.MinIPv6
+	if supernet.Addr().Is4() {
+		minBits = s.policy.MinIPv4
+	}
+	if supernet.Bits() < minBits || s.avoid.Overlaps(supernet) {
+		return
+	}
+
+	// Account for every node the supernet would swallow
+	covered := 0.0
+	longest := 0
+	for m := n; m != nil && supernet.Contains(m.prefix.Addr()); m = m.prev {
+		covered += prefixSize(m.prefix)
+		longest = max(longest, m.longest)
+	}
+	for m := next; m != nil && supernet.Contains(m.prefix.Addr()); m = m.next {
+		covered += prefixSize(m.prefix)
+		longest = max(longest, m.longest)
+	}
+	if longest-supernet.Bits() > s.maxWiden {
+		return
+	}
+
+	heap.Push(&s.heap, &mergeCandidate{
+		left:         n,
+		right:        next,
+		leftVersion:  n.version,
+		rightVersion: next.version,
+		supernet:     supernet,
+		extra:        prefixSize(supernet) - covered,
+	})
+}
+
+// commonSupernet returns the smallest prefix covering a and b, which must be
+// of the same address family
+func commonSupernet(a, b netip.Prefix) netip.Prefix {
+	bits := min(a.Bits(), b.Bits())
+	x, y := a.Addr().As16(), b.Addr().As16()
+	offset := 0
+	if a.Addr().Is4() {
+		offset = 96
+	}
+
+	common := 0
+	for i := offset / 8; i < 16; i++ {
+		if d := x[i] ^ y[i]; d != 0 {
+			for d&0x80 == 0 {
+				d <<= 1
+				common++
+			}
+			break
+		}
+		common += 8
+	}
+	return netip.PrefixFrom(a.Addr(), min(bits, common)).Masked()
+}
+
+// prefixSize returns the number of addresses in p as a float, which is
+// precise enough to rank merges
+func prefixSize(p netip.Prefix) float64 {
+	return math.Ldexp(1, p.Addr().BitLen()-p.Bits())
+}
+
+// addressCount returns the number of addresses in disjoint prefixes
+func addressCount(prefixes []netip.Prefix) *big.Int {
+	total := new(big.Int)
+	for _, p := range prefixes {
+		total.Add(total, new(big.Int).Lsh(big.NewInt(1), uint(p.Addr().BitLen()-p.Bits())))
+	}
+	return total
+}
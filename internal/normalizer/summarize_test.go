@@ -0,0 +1,224 @@
+package normalizer
+
+import (
+	"math/big"
+	"math/rand/v2"
+	"net/netip"
+	"slices"
+	"testing"
+)
+
+func TestSummarize(t *testing.T) {
+	tests := []struct {
+		name     string
+		in       []string
+		allow    []string
+		reserved bool // Avoid the reserved ranges too
+		policy   LengthPolicy
+		target   int
+		maxWiden int
+		want     []string
+		extra    int64
+		reached  bool
+	}{
+		{
+			name:     "under target is only aggregated",
+			in:       []string{"192.0.2.0/25", "192.0.2.128/25", "198.51.100.0/24"},
+			target:   2,
+			maxWiden: 8,
+			want:     []string{"192.0.2.0/24", "198.51.100.0/24"},
+			reached:  true,
+		},
+		{
+			name:     "cheapest merge first",
+			in:       []string{"10.0.0.0/24", "10.0.2.0/24", "10.1.0.0/24"},
+			target:   2,
+			maxWiden: 8,
+			want:     []string{"10.0.0.0/22", "10.1.0.0/24"},
+			extra:    512,
+			reached:  true,
+		},
+		{
+			name:     "allowlist is never widened into",
+			in:       []string{"10.0.0.0/24", "10.0.2.0/24", "10.1.0.0/24"},
+			allow:    []string{"10.0.1.7/32"},
+			target:   2,
+			maxWiden: 16,
+			want:     []string{"10.0.0.0/24", "10.0.2.0/24", "10.1.0.0/24"},
+			reached:  false,
+		},
+		{
+			name:     "allowlist elsewhere does not block",
+			in:       []string{"10.0.0.0/24", "10.0.2.0/24", "10.1.0.0/24"},
+			allow:    []string{"10.0.4.0/24"},
+			target:   2,
+			maxWiden: 8,
+			want:     []string{"10.0.0.0/22", "10.1.0.0/24"},
+			extra:    512,
+			reached:  true,
+		},
+		{
+			name:     "maxWiden respected",
+			in:       []string{"10.0.0.0/24", "10.0.2.0/24"},
+			target:   1,
+			maxWiden: 1,
+			want:     []string{"10.0.0.0/24", "10.0.2.0/24"},
+			reached:  false,
+		},
+		{
+			name:     "maxWiden counts the longest covered prefix",
+			in:       []string{"10.0.0.0/22", "10.0.4.1/32"},
+			target:   1,
+			maxWiden: 8,
+			want:     []string{"10.0.0.0/22", "10.0.4.1/32"},
+			reached:  false,
+		},
+		{
+			name:     "reserved ranges are never widened into",
+			in:       []string{"172.15.0.0/16", "172.32.0.0/16"},
+			reserved: true,
+			target:   1,
+			maxWiden: 8,
+			want:     []string{"172.15.0.0/16", "172.32.0.0/16"},
+			reached:  false,
+		},
+		{
+			name:     "reserved ranges only avoided when passed",
+			in:       []string{"172.15.0.0/16", "172.32.0.0/16"},
+			target:   1,
+			maxWiden: 8,
+			want:     []string{"172.0.0.0/10"},
+			extra:    1<<22 - 2<<16,
+			reached:  true,
+		},
+		{
+			name:     "minimum length respected",
+			in:       []string{"10.0.0.0/24", "10.0.2.0/24", "2001:db8::/48", "2001:db8:2::/48"},
+			policy:   LengthPolicy{MinIPv4: 23, MinIPv6: 47},
+			target:   2,
+			maxWiden: 8,
+			want:     []string{"10.0.0.0/24", "10.0.2.0/24", "2001:db8::/48", "2001:db8:2::/48"},
+			reached:  false,
+		},
+		{
+			name:     "minimum length allows the supernet",
+			in:       []string{"10.0.0.0/24", "10.0.2.0/24", "2001:db8::/48", "2001:db8:2::/48"},
+			policy:   LengthPolicy{MinIPv4: 22, MinIPv6: 47},
+			target:   3,
+			maxWiden: 8,
+			want:     []string{"10.0.0.0/22", "2001:db8::/48", "2001:db8:2::/48"},
+			extra:    512,
+			reached:  true,
+		},
+		{
+			name:     "families are never merged",
+			in:       []string{"10.0.0.0/24", "2001:db8::/48"},
+			target:   1,
+			maxWiden: 128,
+			want:     []string{"10.0.0.0/24", "2001:db8::/48"},
+			reached:  false,
+		},
+	}
+	for _, tt := range tests {
+		avoid := mustPrefixes(t, tt.allow...)
+		if tt.reserved {
+			avoid = append(avoid, Reserved()...)
+		}
+		got, summary := Summarize(mustPrefixes(t, tt.in...), avoid, tt.policy, tt.target, tt.maxWiden)
+		if want := mustPrefixes(t, tt.want...); !slices.Equal(got, want) {
+			t.Errorf("%s: Summarize = %v, want %v", tt.name, got, want)
+		}
+		if summary.ExtraAddresses.Cmp(big.NewInt(tt.extra)) != 0 {
+			t.Errorf("%s: ExtraAddresses = %s, want %d", tt.name, summary.ExtraAddresses, tt.extra)
+		}
+		if summary.TargetReached != tt.reached {
+			t.Errorf("%s: TargetReached = %v, want %v", tt.name, summary.TargetReached, tt.reached)
+		}
+		if summary.After != len(got) {
+			t.Errorf("%s: After = %d, want %d", tt.name, summary.After, len(got))
+		}
+	}
+}
+
+// TestSummarizeProperties checks on random lists that Summarize keeps every
+// listed address, never touches the allowlist, respects maxWiden and reports
+// the exact number of extra addresses without going below the minimum
+// lengths
+func TestSummarizeProperties(t *testing.T) {
+	r := rand.New(rand.NewPCG(7, 8))
+
+	merged := 0
+	for round := 0; round < 200; round++ {
+		allow := make([]netip.Prefix, r.IntN(4))
+		for i := range allow {
+			allow[i] = smallPrefix(r)
+		}
+		in := make([]netip.Prefix, 1+r.IntN(60))
+		for i := range in {
+			in[i] = smallPrefix(r)
+		}
+		// The syncer subtracts the allowlist before summarizing
+		in, _ = Subtract(Normalize(in), Normalize(allow))
+		target := r.IntN(len(in) + 1)
+		maxWiden := r.IntN(10)
+		policy := LengthPolicy{MinIPv4: 20 + r.IntN(8), MinIPv6: 116 + r.IntN(8)}
+
+		got, summary := Summarize(in, allow, policy, target, maxWiden)
+		if summary.Merges > 0 {
+			merged++
+		}
+		aggregated := Aggregate(slices.Clone(in))
+		inAddrs, gotAddrs := addressSet(in), addressSet(got)
+
+		for addr := range inAddrs {
+			if !gotAddrs[addr] {
+				t.Fatalf("round %d: %s dropped", round, addr)
+			}
+		}
+		for _, p := range got {
+			minBits := policy.MinIPv6
+			if p.Addr().Is4() {
+				minBits = policy.MinIPv4
+			}
+			for _, a := range allow {
+				if p.Overlaps(a) {
+					t.Fatalf("round %d: %s overlaps allowlisted %s", round, p, a)
+				}
+			}
+			for _, q := range aggregated {
+				if p.Contains(q.Addr()) && q.Bits()-p.Bits() > maxWiden {
+					t.Fatalf("round %d: %s widens %s by more than %d bits", round, p, q, maxWiden)
+				}
+				// Only supernets are held to the policy; inputs pass unchanged
+				if p.Contains(q.Addr()) && p != q && p.Bits() < minBits {
+					t.Fatalf("round %d: supernet %s is shorter than %+v", round, p, policy)
+				}
+			}
+		}
+
+		extra := int64(len(gotAddrs) - len(inAddrs))
+		if summary.ExtraAddresses.Cmp(big.NewInt(extra)) != 0 {
+			t.Fatalf("round %d: ExtraAddresses = %s, want %d", round, summary.ExtraAddresses, extra)
+		}
+
+		if !slices.IsSortedFunc(got, comparePrefix) {
+			t.Fatalf("round %d: %v is not sorted", round, got)
+		}
+		for i := 1; i < len(got); i++ {
+			if got[i-1].Overlaps(got[i]) {
+				t.Fatalf("round %d: %s overlaps %s", round, got[i-1], got[i])
+			}
+		}
+
+		if summary.Before != len(aggregated) || summary.After != len(got) || summary.TargetReached != (len(got) <= target) {
+			t.Fatalf("round %d: inconsistent summary %+v for %d aggregated, %d returned, target %d",
+				round, summary, len(aggregated), len(got), target)
+		}
+		if len(aggregated) <= target && !slices.Equal(got, aggregated) {
+			t.Fatalf("round %d: under target but %v changed to %v", round, aggregated, got)
+		}
+	}
+	if merged == 0 {
+		t.Fatal("no round merged anything")
+	}
+}
@@ -0,0 +1,268 @@
+package normalizer
+
+import (
+	"iter"
+	"net/netip"
+)
+
+// Trie is a binary radix trie mapping IPv4 and IPv6 prefixes to values.
+// Chains of single-child nodes are compressed, so lookups take at most one
+// step per stored prefix length along the path. Prefixes are canonicalized
+// like Normalize does; invalid ones are ignored. The zero value is an empty
+// trie.
+type Trie[V any] struct {
+	root4 *trieNode[V]
+	root6 *trieNode[V]
+	size  int
+}
+
+// trieNode is a stored prefix or a branch point where two subtrees diverge
+type trieNode[V any] struct {
+	prefix   netip.Prefix
+	value    V
+	set      bool // prefix was inserted, not just a branch point
+	children [2]*trieNode[V]
+}
+
+// NewTrie returns a trie holding prefixes, each mapped to the zero value
+func NewTrie[V any](prefixes []netip.Prefix) *Trie[V] {
+	t := &Trie[V]{}
+	var zero V
+	for _, p := range prefixes {
+		t.Insert(p, zero)
+	}
+	return t
+}
+
+// Len returns the number of stored prefixes
+func (t *Trie[V]) Len() int {
+	return t.size
+}
+
+// root returns the root slot for p's address family
+func (t *Trie[V]) root(p netip.Prefix) **trieNode[V] {
+	if p.Addr().Is4() {
+		return &t.root4
+	}
+	return &t.root6
+}
+
+// Insert stores p with value v, replacing the value if p is already stored
+func (t *Trie[V]) Insert(p netip.Prefix, v V) {
+	p, ok := canonical(p)
+	if !ok {
+		return
+	}
+
+	slot := t.root(p)
+	for {
+		n := *slot
+		switch {
+		case n == nil:
+			*slot = &trieNode[V]{prefix: p, value: v, set: true}
+		case n.prefix == p:
+			if !n.set {
+				t.size++
+			}
+			n.value, n.set = v, true
+			return
+		case n.prefix.Bits() < p.Bits() && n.prefix.Contains(p.Addr()):
+			slot = &n.children[addrBit(p.Addr(), n.prefix.Bits())]
+			continue
+		default:
+			// p covers n or the two diverge below their common supernet
+			leaf := &trieNode[V]{prefix: p, value: v, set: true}
+			if common := commonSupernet(n.prefix, p); common == p {
+				leaf.children[addrBit(n.prefix.Addr(), p.Bits())] = n
+				*slot = leaf
+			} else {
+				branch := &trieNode[V]{prefix: common}
+				branch.children[addrBit(n.prefix.Addr(), common.Bits())] = n
+				branch.children[addrBit(p.Addr(), common.Bits())] = leaf
+				*slot = branch
+			}
+		}
+		t.size++
+		return
+	}
+}
+
+// Delete removes p and reports whether it was stored
+func (t *Trie[V]) Delete(p netip.Prefix) bool {
+	p, ok := canonical(p)
+	if !ok {
+		return false
+	}
+
+	var parent **trieNode[V]
+	slot := t.root(p)
+	for n := *slot; n != nil; n = *slot {
+		if n.prefix == p {
+			if !n.set {
+				return false
+			}
+			var zero V
+			n.value, n.set = zero, false
+			t.size--
+			compact(slot)
+			if parent != nil {
+				compact(parent)
+			}
+			return true
+		}
+		if n.prefix.Bits() >= p.Bits() || !n.prefix.Contains(p.Addr()) {
+			break
+		}
+		parent = slot
+		slot = &n.children[addrBit(p.Addr(), n.prefix.Bits())]
+	}
+	return false
+}
+
+// compact removes the branch point in slot if it no longer joins two subtrees
+func compact[V any](slot **trieNode[V]) {
+	n := *slot
+	if n.set {
+		return
+	}
+	switch {
+	case n.children[0] == nil:
+		*slot = n.children[1]
+	case n.children[1] == nil:
+		*slot = n.children[0]
+	}
+}
+
+// Get returns the value stored for exactly p
+func (t *Trie[V]) Get(p netip.Prefix) (V, bool) {
+	var zero V
+	p, ok := canonical(p)
+	if !ok {
+		return zero, false
+	}
+
+	for n := *t.root(p); n != nil; n = n.children[addrBit(p.Addr(), n.prefix.Bits())] {
+		if n.prefix == p {
+			if n.set {
+				return n.value, true
+			}
+			return zero, false
+		}
+		if n.prefix.Bits() >= p.Bits() || !n.prefix.Contains(p.Addr()) {
+			break
+		}
+	}
+	return zero, false
+}
+
+// LongestMatch returns the longest stored prefix covering p (p itself if it
+// is stored) and its value
+func (t *Trie[V]) LongestMatch(p netip.Prefix) (netip.Prefix, V, bool) {
+	var match *trieNode[V]
+	for n := range t.path(p) {
+		if n.set {
+			match = n
+		}
+	}
+	if match == nil {
+		var zero V
+		return netip.Prefix{}, zero, false
+	}
+	return match.prefix, match.value, true
+}
+
+// Covering iterates over the stored prefixes covering p, including p itself,
+// shortest first
+func (t *Trie[V]) Covering(p netip.Prefix) iter.Seq2[netip.Prefix, V] {
+	return func(yield func(netip.Prefix, V) bool) {
+		for n := range t.path(p) {
+			if n.set && !yield(n.prefix, n.value) {
+				return
+			}
+		}
+	}
+}
+
+// CoveredBy iterates over the stored prefixes covered by p, including p
+// itself, in the order Normalize sorts them
+func (t *Trie[V]) CoveredBy(p netip.Prefix) iter.Seq2[netip.Prefix, V] {
+	return func(yield func(netip.Prefix, V) bool) {
+		p, ok := canonical(p)
+		if !ok {
+			return
+		}
+
+		// Descend to the first node inside p; its subtree is everything p covers
+		n := *t.root(p)
+		for n != nil && n.prefix.Bits() < p.Bits() && n.prefix.Contains(p.Addr()) {
+			n = n.children[addrBit(p.Addr(), n.prefix.Bits())]
+		}
+		if n != nil && n.prefix.Bits() >= p.Bits() && p.Contains(n.prefix.Addr()) {
+			n.walk(yield)
+		}
+	}
+}
+
+// Overlaps reports whether any stored prefix covers or is covered by p
+func (t *Trie[V]) Overlaps(p netip.Prefix) bool {
+	for range t.Covering(p) {
+		return true
+	}
+	for range t.CoveredBy(p) {
+		return true
+	}
+	return false
+}
+
+// All iterates over every stored prefix in the order Normalize sorts them
+func (t *Trie[V]) All() iter.Seq2[netip.Prefix, V] {
+	return func(yield func(netip.Prefix, V) bool) {
+		if t.root4 != nil && !t.root4.walk(yield) {
+			return
+		}
+		if t.root6 != nil {
+			t.root6.walk(yield)
+		}
+	}
+}
+
+// path iterates over the nodes whose prefix covers p, shortest first
+func (t *Trie[V]) path(p netip.Prefix) iter.Seq[*trieNode[V]] {
+	return func(yield func(*trieNode[V]) bool) {
+		p, ok := canonical(p)
+		if !ok {
+			return
+		}
+
+		for n := *t.root(p); n != nil && n.prefix.Bits() <= p.Bits() && n.prefix.Contains(p.Addr()); {
+			if !yield(n) || n.prefix.Bits() == p.Bits() {
+				return
+			}
+			n = n.children[addrBit(p.Addr(), n.prefix.Bits())]
+		}
+	}
+}
+
+// walk yields the stored prefixes of the subtree in pre-order, which is
+// address order with shorter prefixes first, and reports whether to go on
+func (n *trieNode[V]) walk(yield func(netip.Prefix, V) bool) bool {
+	if n.set && !yield(n.prefix, n.value) {
+		return false
+	}
+	for _, child := range n.children {
+		if child != nil && !child.walk(yield) {
+			return false
+		}
+	}
+	return true
+}
+
+// addrBit returns bit i of addr, counting from the most significant bit
+func addrBit(addr netip.Addr, i int) int {
+	if addr.Is4() {
+		b := addr.As4()
+		return int(b[i/8]>>(7-i%8)) & 1
+	}
+	b := addr.As16()
+	return int(b[i/8]>>(7-i%8)) & 1
+}
@@ -0,0 +1,294 @@
+package normalizer
+
+import (
+	"encoding/binary"
+	"math/rand/v2"
+	"net/netip"
+	"slices"
+	"testing"
+)
+
+// collect gathers the prefixes of an iterator
+func collect[V any](seq func(yield func(netip.Prefix, V) bool)) []netip.Prefix {
+	var result []netip.Prefix
+	for p := range seq {
+		result = append(result, p)
+	}
+	return result
+}
+
+// mustPrefixes parses a list of CIDRs
+func mustPrefixes(t *testing.T, cidrs ...string) []netip.Prefix {
+	t.Helper()
+	result := make([]netip.Prefix, len(cidrs))
+	for i, c := range cidrs {
+		result[i] = netip.MustParsePrefix(c)
+	}
+	return result
+}
+
+func TestTrieInsertGetDelete(t *testing.T) {
+	var trie Trie[string]
+	trie.Insert(netip.MustParsePrefix("10.0.0.0/8"), "a")
+	trie.Insert(netip.MustParsePrefix("10.1.0.0/16"), "b")
+	trie.Insert(netip.MustParsePrefix("10.2.0.0/16"), "c")
+	trie.Insert(netip.MustParsePrefix("2001:db8::/32"), "d")
+	trie.Insert(netip.MustParsePrefix("10.1.0.0/16"), "b2")         // replaces
+	trie.Insert(netip.MustParsePrefix("10.3.4.5/16"), "e")          // host bits cleared
+	trie.Insert(netip.MustParsePrefix("::ffff:192.0.2.0/120"), "f") // unmapped to 192.0.2.0/24
+	trie.Insert(netip.Prefix{}, "invalid")                          // ignored
+
+	if got := trie.Len(); got != 6 {
+		t.Errorf("Len = %d, want 6", got)
+	}
+
+	tests := []struct {
+		prefix string
+		want   string
+		ok     bool
+	}{
+		{"10.0.0.0/8", "a", true},
+		{"10.1.0.0/16", "b2", true},
+		{"10.2.0.0/16", "c", true},
+		{"10.3.0.0/16", "e", true},
+		{"192.0.2.0/24", "f", true},
+		{"2001:db8::/32", "d", true},
+		{"10.0.0.0/14", "", false}, // branch point, not stored
+		{"10.0.0.0/16", "", false},
+		{"10.1.2.0/24", "", false},
+		{"0.0.0.0/0", "", false},
+		{"2001:db8::/48", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := trie.Get(netip.MustParsePrefix(tt.prefix))
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("Get(%s) = %q, %v; want %q, %v", tt.prefix, got, ok, tt.want, tt.ok)
+		}
+	}
+
+	if trie.Delete(netip.MustParsePrefix("10.0.0.0/14")) {
+		t.Error("Delete of a branch point reported true")
+	}
+	if !trie.Delete(netip.MustParsePrefix("10.0.0.0/8")) {
+		t.Error("Delete(10.0.0.0/8) = false")
+	}
+	if trie.Delete(netip.MustParsePrefix("10.0.0.0/8")) {
+		t.Error("second Delete(10.0.0.0/8) = true")
+	}
+	if _, ok := trie.Get(netip.MustParsePrefix("10.0.0.0/8")); ok {
+		t.Error("10.0.0.0/8 still stored after Delete")
+	}
+	if got, ok := trie.Get(netip.MustParsePrefix("10.1.0.0/16")); !ok || got != "b2" {
+		t.Errorf("Delete removed a covered prefix: %q, %v", got, ok)
+	}
+	if got := trie.Len(); got != 5 {
+		t.Errorf("Len after Delete = %d, want 5", got)
+	}
+}
+
+func TestTrieQueries(t *testing.T) {
+	trie := NewTrie[struct{}](mustPrefixes(t,
+		"10.0.0.0/8", "10.1.0.0/16", "10.1.2.0/24", "10.1.2.3/32", "10.2.0.0/16",
+		"192.0.2.0/24", "2001:db8::/32", "2001:db8:1::/48",
+	))
+
+	tests := []struct {
+		query     string
+		longest   string
+		covering  []string
+		coveredBy []string
+	}{
+		{
+			query:     "10.1.2.3/32",
+			longest:   "10.1.2.3/32",
+			covering:  []string{"10.0.0.0/8", "10.1.0.0/16", "10.1.2.0/24", "10.1.2.3/32"},
+			coveredBy: []string{"10.1.2.3/32"},
+		},
+		{
+			query:     "10.1.2.4/32",
+			longest:   "10.1.2.0/24",
+			covering:  []string{"10.0.0.0/8", "10.1.0.0/16", "10.1.2.0/24"},
+			coveredBy: nil,
+		},
+		{
+			query:     "10.1.0.0/16",
+			longest:   "10.1.0.0/16",
+			covering:  []string{"10.0.0.0/8", "10.1.0.0/16"},
+			coveredBy: []string{"10.1.0.0/16", "10.1.2.0/24", "10.1.2.3/32"},
+		},
+		{
+			query:     "10.0.0.0/7",
+			longest:   "",
+			covering:  nil,
+			coveredBy: []string{"10.0.0.0/8", "10.1.0.0/16", "10.1.2.0/24", "10.1.2.3/32", "10.2.0.0/16"},
+		},
+		{
+			query:     "10.0.0.0/14",
+			longest:   "10.0.0.0/8",
+			covering:  []string{"10.0.0.0/8"},
+			coveredBy: []string{"10.1.0.0/16", "10.1.2.0/24", "10.1.2.3/32", "10.2.0.0/16"},
+		},
+		{
+			query:     "0.0.0.0/0",
+			covering:  nil,
+			coveredBy: []string{"10.0.0.0/8", "10.1.0.0/16", "10.1.2.0/24", "10.1.2.3/32", "10.2.0.0/16", "192.0.2.0/24"},
+		},
+		{
+			query:     "172.16.0.0/12",
+			covering:  nil,
+			coveredBy: nil,
+		},
+		{
+			query:     "2001:db8:1:2::/64",
+			longest:   "2001:db8:1::/48",
+			covering:  []string{"2001:db8::/32", "2001:db8:1::/48"},
+			coveredBy: nil,
+		},
+		{
+			query:     "::ffff:10.1.2.3/128",
+			longest:   "10.1.2.3/32",
+			covering:  []string{"10.0.0.0/8", "10.1.0.0/16", "10.1.2.0/24", "10.1.2.3/32"},
+			coveredBy: []string{"10.1.2.3/32"},
+		},
+	}
+	for _, tt := range tests {
+		q := netip.MustParsePrefix(tt.query)
+
+		p, _, ok := trie.LongestMatch(q)
+		if tt.longest == "" {
+			if ok {
+				t.Errorf("LongestMatch(%s) = %s, want none", tt.query, p)
+			}
+		} else if !ok || p != netip.MustParsePrefix(tt.longest) {
+			t.Errorf("LongestMatch(%s) = %s, %v; want %s", tt.query, p, ok, tt.longest)
+		}
+
+		if got, want := collect(trie.Covering(q)), mustPrefixes(t, tt.covering...); !slices.Equal(got, want) {
+			t.Errorf("Covering(%s) = %v, want %v", tt.query, got, want)
+		}
+		if got, want := collect(trie.CoveredBy(q)), mustPrefixes(t, tt.coveredBy...); !slices.Equal(got, want) {
+			t.Errorf("CoveredBy(%s) = %v, want %v", tt.query, got, want)
+		}
+		if got, want := trie.Overlaps(q), len(tt.covering)+len(tt.coveredBy) > 0; got != want {
+			t.Errorf("Overlaps(%s) = %v, want %v", tt.query, got, want)
+		}
+	}
+
+	want := mustPrefixes(t,
+		"10.0.0.0/8", "10.1.0.0/16", "10.1.2.0/24", "10.1.2.3/32", "10.2.0.0/16",
+		"192.0.2.0/24", "2001:db8::/32", "2001:db8:1::/48",
+	)
+	if got := collect(trie.All()); !slices.Equal(got, want) {
+		t.Errorf("All = %v, want %v", got, want)
+	}
+}
+
+func TestTrieIteratorStops(t *testing.T) {
+	trie := NewTrie[struct{}](mustPrefixes(t, "10.0.0.0/8", "10.1.0.0/16", "10.1.2.0/24", "2001:db8::/32"))
+
+	n := 0
+	for range trie.All() {
+		n++
+		if n == 2 {
+			break
+		}
+	}
+	if n != 2 {
+		t.Errorf("All yielded %d prefixes after break, want 2", n)
+	}
+}
+
+// randomPrefix returns a random prefix packed into a small part of the
+// address space so prefixes often nest and share branch points
+func randomPrefix(r *rand.Rand) netip.Prefix {
+	if r.IntN(4) == 0 {
+		var b [16]byte
+		b[0], b[1] = 0x20, 0x01
+		binary.BigEndian.PutUint16(b[2:4], uint16(r.IntN(4)))
+		binary.BigEndian.PutUint16(b[4:6], uint16(r.Uint32()))
+		return netip.PrefixFrom(netip.AddrFrom16(b), 14+r.IntN(36)).Masked()
+	}
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], 0x0a000000|r.Uint32()&0x000fffff)
+	return netip.PrefixFrom(netip.AddrFrom4(b), 6+r.IntN(27)).Masked()
+}
+
+// TestTrieMatchesBruteForce checks every trie query against a linear scan
+// of the stored prefixes through random inserts and deletes
+func TestTrieMatchesBruteForce(t *testing.T) {
+	r := rand.New(rand.NewPCG(3, 4))
+
+	for round := 0; round < 50; round++ {
+		var trie Trie[int]
+		stored := make(map[netip.Prefix]int)
+
+		for op := 0; op < 200; op++ {
+			p := randomPrefix(r)
+			if r.IntN(3) == 0 {
+				_, want := stored[p]
+				if got := trie.Delete(p); got != want {
+					t.Fatalf("Delete(%s) = %v, want %v", p, got, want)
+				}
+				delete(stored, p)
+			} else {
+				trie.Insert(p, op)
+				stored[p] = op
+			}
+		}
+
+		if trie.Len() != len(stored) {
+			t.Fatalf("Len = %d, want %d", trie.Len(), len(stored))
+		}
+
+		all := make([]netip.Prefix, 0, len(stored))
+		for p := range stored {
+			all = append(all, p)
+		}
+		sortPrefixes(all)
+		if got := collect(trie.All()); !slices.Equal(got, all) {
+			t.Fatalf("All = %v, want %v", got, all)
+		}
+
+		for q := 0; q < 200; q++ {
+			query := randomPrefix(r)
+			if q%4 == 0 && len(all) > 0 {
+				query = all[r.IntN(len(all))]
+			}
+
+			var covering, coveredBy []netip.Prefix
+			for _, p := range all {
+				if p.Bits() <= query.Bits() && p.Contains(query.Addr()) {
+					covering = append(covering, p)
+				}
+				if p.Bits() >= query.Bits() && query.Contains(p.Addr()) {
+					coveredBy = append(coveredBy, p)
+				}
+			}
+			slices.SortFunc(covering, func(a, b netip.Prefix) int { return a.Bits() - b.Bits() })
+
+			v, ok := trie.Get(query)
+			if wantV, wantOK := stored[query]; ok != wantOK || v != wantV {
+				t.Fatalf("Get(%s) = %d, %v; want %d, %v", query, v, ok, wantV, wantOK)
+			}
+
+			p, v, ok := trie.LongestMatch(query)
+			if len(covering) == 0 {
+				if ok {
+					t.Fatalf("LongestMatch(%s) = %s, want none", query, p)
+				}
+			} else if want := covering[len(covering)-1]; !ok || p != want || v != stored[want] {
+				t.Fatalf("LongestMatch(%s) = %s, %d; want %s, %d", query, p, v, want, stored[want])
+			}
+
+			if got := collect(trie.Covering(query)); !slices.Equal(got, covering) {
+				t.Fatalf("Covering(%s) = %v, want %v", query, got, covering)
+			}
+			if got := collect(trie.CoveredBy(query)); !slices.Equal(got, coveredBy) {
+				t.Fatalf("CoveredBy(%s) = %v, want %v", query, got, coveredBy)
+			}
+			if got, want := trie.Overlaps(query), len(covering)+len(coveredBy) > 0; got != want {
+				t.Fatalf("Overlaps(%s) = %v, want %v", query, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,235 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/0x4272616E646F6E/unifi-threat-sync/internal/config"
+)
+
+// EventType identifies what happened during a sync
+type EventType string
+
+const (
+	// EventFailure is sent when a sync run fails
+	EventFailure EventType = "failure"
+	// EventSafety is sent when a safety limit stops a push
+	EventSafety EventType = "safety"
+	// EventLargeChange is sent when a push adds or removes many entries
+	EventLargeChange EventType = "largeChange"
+	// EventRecovery is sent on the first successful run after a failure
+	EventRecovery EventType = "recovery"
+)
+
+// Event describes a sync outcome worth notifying about
+type Event struct {
+	Type      EventType `json:"type"`
+	Profile   string    `json:"profile,omitempty"`
+	Group     string    `json:"group,omitempty"`
+	Message   string    `json:"message"`
+	Added     int       `json:"added"`
+	Removed   int       `json:"removed"`
+	Error     string    `json:"error,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// target is a configured notification destination
+type target struct {
+	name       string
+	kind       string
+	url        string
+	headers    map[string]string
+	events     map[EventType]bool
+	template   *template.Template
+	retries    int
+	retryDelay time.Duration
+}
+
+// Notifier delivers events to all configured targets
+type Notifier struct {
+	targets []target
+	client  *http.Client
+	wg      sync.WaitGroup
+}
+
+// New creates a Notifier from the notify configuration
+func New(cfg config.NotifyConfig) (*Notifier, error) {
+	n := &Notifier{
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+
+	for i, t := range cfg.Targets {
+		tg := target{
+			name:       t.Name,
+			kind:       t.Type,
+			url:        t.URL,
+			headers:    t.Headers,
+			retries:    t.RetryCount(),
+			retryDelay: t.RetryDelay,
+		}
+		if len(t.Events) > 0 {
+			tg.events = make(map[EventType]bool, len(t.Events))
+			for _, e := range t.Events {
+				tg.events[EventType(e)] = true
+			}
+		}
+		if t.Template != "" {
+			tmpl, err := template.New(t.Name).Funcs(templateFuncs).Parse(t.Template)
+			if err != nil {
+				return nil, fmt.Errorf("notify.targets[%d].template: %w", i, err)
+			}
+			tg.template = tmpl
+		}
+		n.targets = append(n.targets, tg)
+	}
+
+	return n, nil
+}
+
+// templateFuncs are available in webhook payload templates
+var templateFuncs = template.FuncMap{
+	// json encodes a value, e.g. {"text": {{json .Message}}}
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+// Notify sends the event to every target subscribed to its type. Delivery
+// happens in the background; use Wait to block until it finishes.
+func (n *Notifier) Notify(ctx context.Context, event Event) {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now().UTC()
+	}
+
+	for _, t := range n.targets {
+		if t.events != nil && !t.events[event.Type] {
+			continue
+		}
+
+		n.wg.Add(1)
+		go func(t target) {
+			defer n.wg.Done()
+			if err := n.send(context.WithoutCancel(ctx), t, event); err != nil {
+				fmt.Printf("Warning: notification to %s failed: %v\n", t.name, err)
+			}
+		}(t)
+	}
+}
+
+// Wait blocks until all pending notifications have been delivered or failed
+func (n *Notifier) Wait() {
+	n.wg.Wait()
+}
+
+// send delivers an event to one target, retrying on failure
+func (n *Notifier) send(ctx context.Context, t target, event Event) error {
+	body, err := t.payload(event)
+	if err != nil {
+		return fmt.Errorf("failed to build payload: %w", err)
+	}
+
+	delay := t.retryDelay
+	for attempt := 0; ; attempt++ {
+		err = n.post(ctx, t, body)
+		if err == nil || attempt >= t.retries {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// post makes a single delivery attempt
+func (n *Notifier) post(ctx context.Context, t target, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", t.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "UniFi-Threat-Sync/1.0")
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+
+	return nil
+}
+
+// payload renders the request body for the target's format
+func (t target) payload(event Event) ([]byte, error) {
+	switch t.kind {
+	case "slack":
+		return json.Marshal(map[string]string{"text": formatText(event)})
+	case "teams":
+		return json.Marshal(map[string]string{
+			"@type":      "MessageCard",
+			"@context":   "https://schema.org/extensions",
+			"summary":    event.Message,
+			"themeColor": themeColor(event.Type),
+			"title":      fmt.Sprintf("UniFi Threat Sync: %s", event.Type),
+			"text":       formatText(event),
+		})
+	default:
+		if t.template == nil {
+			return json.Marshal(event)
+		}
+		var buf bytes.Buffer
+		if err := t.template.Execute(&buf, event); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+}
+
+// formatText renders a human-readable summary for chat targets
+func formatText(event Event) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[%s] %s", event.Type, event.Message)
+	if event.Group != "" {
+		fmt.Fprintf(&b, "\nGroup: %s (profile %s)", event.Group, event.Profile)
+	}
+	if event.Added > 0 || event.Removed > 0 {
+		fmt.Fprintf(&b, "\nAdded: %d, Removed: %d", event.Added, event.Removed)
+	}
+	if event.Error != "" {
+		fmt.Fprintf(&b, "\nError: %s", event.Error)
+	}
+	return b.String()
+}
+
+// themeColor picks a Teams card color for the event type
+func themeColor(t EventType) string {
+	switch t {
+	case EventFailure, EventSafety:
+		return "D70000"
+	case EventRecovery:
+		return "2EB886"
+	default:
+		return "FFA500"
+	}
+}
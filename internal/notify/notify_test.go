@@ -0,0 +1,301 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/0x4272616E646F6E/unifi-threat-sync/internal/config"
+)
+
+// request is a request received by a test server
+type request struct {
+	at      time.Time
+	header  http.Header
+	body    []byte
+	payload map[string]interface{}
+}
+
+// recorder is a test webhook endpoint answering with the given status codes
+// in turn, then 200
+type recorder struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []request
+}
+
+func (rec *recorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	req := request{at: time.Now(), header: r.Header.Clone(), body: body}
+	json.Unmarshal(body, &req.payload)
+
+	rec.mu.Lock()
+	rec.requests = append(rec.requests, req)
+	status := http.StatusOK
+	if len(rec.statuses) > 0 {
+		status, rec.statuses = rec.statuses[0], rec.statuses[1:]
+	}
+	rec.mu.Unlock()
+
+	w.WriteHeader(status)
+}
+
+func (rec *recorder) received() []request {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return append([]request(nil), rec.requests...)
+}
+
+// newTarget starts a recorder and returns it with a target config pointing at it
+func newTarget(t *testing.T, kind string, statuses ...int) (*recorder, config.NotifyTarget) {
+	rec := &recorder{statuses: statuses}
+	srv := httptest.NewServer(rec)
+	t.Cleanup(srv.Close)
+	return rec, config.NotifyTarget{Name: kind, Type: kind, URL: srv.URL, RetryDelay: time.Millisecond}
+}
+
+// ptr returns a pointer to v
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func mustNew(t *testing.T, targets ...config.NotifyTarget) *Notifier {
+	t.Helper()
+	n, err := New(config.NotifyConfig{Targets: targets})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+var testEvent = Event{
+	Type:      EventLargeChange,
+	Profile:   "default",
+	Group:     "uts-block-list",
+	Message:   "Large change pushed to group 'uts-block-list'",
+	Added:     1200,
+	Removed:   3,
+	Timestamp: time.Date(2026, 10, 14, 10, 0, 0, 0, time.UTC),
+}
+
+func TestNotifyRetryBackoff(t *testing.T) {
+	rec, cfg := newTarget(t, "webhook", http.StatusInternalServerError, http.StatusBadGateway)
+	cfg.Retries = ptr(3)
+	cfg.RetryDelay = 20 * time.Millisecond
+
+	n := mustNew(t, cfg)
+	n.Notify(context.Background(), testEvent)
+	n.Wait()
+
+	reqs := rec.received()
+	if len(reqs) != 3 {
+		t.Fatalf("got %d attempts, want 3", len(reqs))
+	}
+	// The delay doubles after each failed attempt
+	if gap := reqs[1].at.Sub(reqs[0].at); gap < 20*time.Millisecond {
+		t.Errorf("first retry after %s, want at least 20ms", gap)
+	}
+	if gap := reqs[2].at.Sub(reqs[1].at); gap < 40*time.Millisecond {
+		t.Errorf("second retry after %s, want at least 40ms", gap)
+	}
+}
+
+func TestNotifyRetriesExhausted(t *testing.T) {
+	rec, cfg := newTarget(t, "webhook", 500, 500, 500, 500)
+	cfg.Retries = ptr(2)
+
+	n := mustNew(t, cfg)
+	err := n.send(context.Background(), n.targets[0], testEvent)
+	if err == nil || !strings.Contains(err.Error(), "unexpected status 500") {
+		t.Errorf("send error = %v, want unexpected status 500", err)
+	}
+	if got := len(rec.received()); got != 3 {
+		t.Errorf("got %d attempts, want 3", got)
+	}
+}
+
+func TestNotifyRetriesDisabled(t *testing.T) {
+	rec, cfg := newTarget(t, "webhook", 500, 500)
+	cfg.Retries = ptr(0)
+
+	n := mustNew(t, cfg)
+	if err := n.send(context.Background(), n.targets[0], testEvent); err == nil {
+		t.Error("send succeeded against a failing target")
+	}
+	if got := len(rec.received()); got != 1 {
+		t.Errorf("got %d attempts, want 1", got)
+	}
+}
+
+func TestNotifyRetriesDefault(t *testing.T) {
+	rec, cfg := newTarget(t, "webhook", 500, 500, 500, 500, 500)
+
+	n := mustNew(t, cfg)
+	n.send(context.Background(), n.targets[0], testEvent)
+	if got := len(rec.received()); got != 4 {
+		t.Errorf("got %d attempts, want 4", got)
+	}
+}
+
+func TestNotifyRetryCancelled(t *testing.T) {
+	rec, cfg := newTarget(t, "webhook", 500, 500)
+	cfg.Retries = ptr(5)
+	cfg.RetryDelay = time.Hour
+
+	n := mustNew(t, cfg)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := n.send(ctx, n.targets[0], testEvent); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("send error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if got := len(rec.received()); got != 1 {
+		t.Errorf("got %d attempts, want 1", got)
+	}
+}
+
+func TestNotifyEventFiltering(t *testing.T) {
+	all, allCfg := newTarget(t, "webhook")
+	failures, failuresCfg := newTarget(t, "webhook")
+	failuresCfg.Events = []string{"failure", "recovery"}
+
+	n := mustNew(t, allCfg, failuresCfg)
+	for _, typ := range []EventType{EventFailure, EventLargeChange, EventRecovery} {
+		n.Notify(context.Background(), Event{Type: typ, Message: string(typ)})
+	}
+	n.Wait()
+
+	if got := len(all.received()); got != 3 {
+		t.Errorf("unfiltered target got %d events, want 3", got)
+	}
+	var types []string
+	for _, r := range failures.received() {
+		types = append(types, r.payload["type"].(string))
+	}
+	if len(types) != 2 || strings.Contains(strings.Join(types, ","), string(EventLargeChange)) {
+		t.Errorf("filtered target got %v, want failure and recovery", types)
+	}
+}
+
+func TestNotifyWebhookPayload(t *testing.T) {
+	rec, cfg := newTarget(t, "webhook")
+	cfg.Headers = map[string]string{"X-Token": "secret"}
+
+	n := mustNew(t, cfg)
+	n.Notify(context.Background(), testEvent)
+	n.Wait()
+
+	reqs := rec.received()
+	if len(reqs) != 1 {
+		t.Fatalf("got %d requests, want 1", len(reqs))
+	}
+	r := reqs[0]
+	if got := r.header.Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q", got)
+	}
+	if got := r.header.Get("X-Token"); got != "secret" {
+		t.Errorf("X-Token = %q, want secret", got)
+	}
+
+	var got Event
+	if err := json.Unmarshal(r.body, &got); err != nil {
+		t.Fatalf("payload is not an event: %v", err)
+	}
+	if got != testEvent {
+		t.Errorf("payload = %+v, want %+v", got, testEvent)
+	}
+}
+
+func TestNotifyTimestampDefault(t *testing.T) {
+	rec, cfg := newTarget(t, "webhook")
+	n := mustNew(t, cfg)
+
+	before := time.Now().UTC()
+	n.Notify(context.Background(), Event{Type: EventFailure, Message: "failed"})
+	n.Wait()
+
+	var got Event
+	if err := json.Unmarshal(rec.received()[0].body, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Timestamp.Before(before.Truncate(time.Second)) {
+		t.Errorf("timestamp %s not set to the send time", got.Timestamp)
+	}
+}
+
+func TestNotifyTemplatePayload(t *testing.T) {
+	rec, cfg := newTarget(t, "webhook")
+	cfg.Template = `{"summary": {{json .Message}}, "kind": "{{.Type}}", "changes": {{.Added}}}`
+
+	n := mustNew(t, cfg)
+	n.Notify(context.Background(), testEvent)
+	n.Wait()
+
+	want := `{"summary": "Large change pushed to group 'uts-block-list'", "kind": "largeChange", "changes": 1200}`
+	if got := string(rec.received()[0].body); got != want {
+		t.Errorf("payload = %s, want %s", got, want)
+	}
+}
+
+func TestNotifyInvalidTemplate(t *testing.T) {
+	_, err := New(config.NotifyConfig{Targets: []config.NotifyTarget{{Name: "bad", Type: "webhook", Template: "{{.Message"}}})
+	if err == nil {
+		t.Error("New accepted an invalid template")
+	}
+}
+
+func TestNotifySlackPayload(t *testing.T) {
+	rec, cfg := newTarget(t, "slack")
+	n := mustNew(t, cfg)
+	n.Notify(context.Background(), testEvent)
+	n.Wait()
+
+	payload := rec.received()[0].payload
+	want := "[largeChange] Large change pushed to group 'uts-block-list'\n" +
+		"Group: uts-block-list (profile default)\nAdded: 1200, Removed: 3"
+	if len(payload) != 1 || payload["text"] != want {
+		t.Errorf("payload = %v, want text %q", payload, want)
+	}
+}
+
+func TestNotifyTeamsPayload(t *testing.T) {
+	rec, cfg := newTarget(t, "teams")
+	n := mustNew(t, cfg)
+	n.Notify(context.Background(), Event{Type: EventFailure, Message: "Sync failed", Error: "controller unreachable"})
+	n.Wait()
+
+	payload := rec.received()[0].payload
+	want := map[string]string{
+		"@type":      "MessageCard",
+		"@context":   "https://schema.org/extensions",
+		"summary":    "Sync failed",
+		"themeColor": "D70000",
+		"title":      "UniFi Threat Sync: failure",
+		"text":       "[failure] Sync failed\nError: controller unreachable",
+	}
+	for k, v := range want {
+		if payload[k] != v {
+			t.Errorf("%s = %v, want %q", k, payload[k], v)
+		}
+	}
+}
+
+func TestThemeColor(t *testing.T) {
+	tests := map[EventType]string{
+		EventFailure:     "D70000",
+		EventSafety:      "D70000",
+		EventRecovery:    "2EB886",
+		EventLargeChange: "FFA500",
+	}
+	for typ, want := range tests {
+		if got := themeColor(typ); got != want {
+			t.Errorf("themeColor(%s) = %s, want %s", typ, got, want)
+		}
+	}
+}
@@ -5,12 +5,13 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"net"
 	"net/http"
+	"net/netip"
 	"strings"
 	"time"
 
 	"github.com/0x4272616E646F6E/unifi-threat-sync/internal/config"
+	"github.com/0x4272616E646F6E/unifi-threat-sync/internal/indicator"
 )
 
 // NetsetParser parses FireHOL netset format feeds
@@ -26,7 +27,7 @@ func (p *NetsetParser) Name() string {
 }
 
 // Parse fetches and parses a netset format feed
-func (p *NetsetParser) Parse(ctx context.Context, feedConfig config.FeedConfig) ([]net.IPNet, error) {
+func (p *NetsetParser) Parse(ctx context.Context, feedConfig config.FeedConfig) ([]netip.Prefix, error) {
 	// Parse timeout
 	timeout := 30 * time.Second
 	if feedConfig.Timeout != "" {
@@ -60,12 +61,15 @@ func (p *NetsetParser) Parse(ctx context.Context, feedConfig config.FeedConfig)
 	}
 
 	// Parse response
-	return p.parseBody(resp.Body)
+	prefixes, report, err := p.parseBody(resp.Body)
+	logCorrections(feedConfig.Name, report)
+	return prefixes, err
 }
 
 // parseBody parses the netset format body
-func (p *NetsetParser) parseBody(body io.Reader) ([]net.IPNet, error) {
-	var networks []net.IPNet
+func (p *NetsetParser) parseBody(body io.Reader) ([]netip.Prefix, indicator.Report, error) {
+	var prefixes []netip.Prefix
+	var report indicator.Report
 	scanner := bufio.NewScanner(body)
 
 	for scanner.Scan() {
@@ -82,24 +86,25 @@ func (p *NetsetParser) parseBody(body io.Reader) ([]net.IPNet, error) {
 		}
 
 		// Parse IP or CIDR
-		ipnet, err := parseIPOrCIDR(line)
+		ind, err := indicator.Parse(line)
 		if err != nil {
 			// Skip invalid lines silently
 			continue
 		}
 
-		networks = append(networks, ipnet)
+		report.Add(ind)
+		prefixes = append(prefixes, ind.Prefix)
 	}
 
 	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading feed: %w", err)
+		return nil, report, fmt.Errorf("error reading feed: %w", err)
 	}
 
-	if len(networks) == 0 {
-		return nil, fmt.Errorf("no valid IPs found in feed")
+	if len(prefixes) == 0 {
+		return nil, report, fmt.Errorf("no valid IPs found in feed")
 	}
 
-	return networks, nil
+	return prefixes, report, nil
 }
 
 // ValidateConfig validates the netset parser configuration
@@ -3,7 +3,7 @@ package parser
 import (
 	"context"
 	"fmt"
-	"net"
+	"net/netip"
 
 	"github.com/0x4272616E646F6E/unifi-threat-sync/internal/config"
 )
@@ -14,7 +14,7 @@ type Parser interface {
 	Name() string
 
 	// Parse fetches and parses the feed, returning a list of IPs/CIDRs
-	Parse(ctx context.Context, feedConfig config.FeedConfig) ([]net.IPNet, error)
+	Parse(ctx context.Context, feedConfig config.FeedConfig) ([]netip.Prefix, error)
 
 	// ValidateConfig validates parser-specific configuration
 	ValidateConfig(feedConfig config.FeedConfig) error
@@ -5,12 +5,14 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"net"
 	"net/http"
+	"net/netip"
+	"os"
 	"strings"
 	"time"
 
 	"github.com/0x4272616E646F6E/unifi-threat-sync/internal/config"
+	"github.com/0x4272616E646F6E/unifi-threat-sync/internal/indicator"
 )
 
 // PlainParser parses plain text feeds with one IP/CIDR per line
@@ -26,7 +28,7 @@ func (p *PlainParser) Name() string {
 }
 
 // Parse fetches and parses a plain text feed
-func (p *PlainParser) Parse(ctx context.Context, feedConfig config.FeedConfig) ([]net.IPNet, error) {
+func (p *PlainParser) Parse(ctx context.Context, feedConfig config.FeedConfig) ([]netip.Prefix, error) {
 	// Parse timeout
 	timeout := 30 * time.Second
 	if feedConfig.Timeout != "" {
@@ -61,12 +63,31 @@ func (p *PlainParser) Parse(ctx context.Context, feedConfig config.FeedConfig) (
 	}
 
 	// Parse response
-	return p.parseBody(resp.Body)
+	prefixes, report, err := p.parseBody(resp.Body)
+	logCorrections(feedConfig.Name, report)
+	return prefixes, err
 }
 
-// parseBody parses the response body line by line
-func (p *PlainParser) parseBody(body io.Reader) ([]net.IPNet, error) {
-	var networks []net.IPNet
+// parseBody parses the response body line by line. A feed without a single
+// valid entry is an error, as it most likely failed.
+func (p *PlainParser) parseBody(body io.Reader) ([]netip.Prefix, indicator.Report, error) {
+	prefixes, report, err := parseLines(body)
+	if err != nil {
+		return nil, report, err
+	}
+
+	if len(prefixes) == 0 {
+		return nil, report, fmt.Errorf("no valid IPs found in feed")
+	}
+
+	return prefixes, report, nil
+}
+
+// parseLines parses one IP/CIDR per line, skipping blank lines, comments and
+// invalid entries
+func parseLines(body io.Reader) ([]netip.Prefix, indicator.Report, error) {
+	var prefixes []netip.Prefix
+	var report indicator.Report
 	scanner := bufio.NewScanner(body)
 
 	for scanner.Scan() {
@@ -85,24 +106,21 @@ func (p *PlainParser) parseBody(body io.Reader) ([]net.IPNet, error) {
 		}
 
 		// Parse IP or CIDR
-		ipnet, err := parseIPOrCIDR(line)
+		ind, err := indicator.Parse(line)
 		if err != nil {
 			// Skip invalid lines silently
 			continue
 		}
 
-		networks = append(networks, ipnet)
+		report.Add(ind)
+		prefixes = append(prefixes, ind.Prefix)
 	}
 
 	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading feed: %w", err)
+		return nil, report, fmt.Errorf("error reading feed: %w", err)
 	}
 
-	if len(networks) == 0 {
-		return nil, fmt.Errorf("no valid IPs found in feed")
-	}
-
-	return networks, nil
+	return prefixes, report, nil
 }
 
 // ValidateConfig validates the plain parser configuration
@@ -112,3 +130,18 @@ func (p *PlainParser) ValidateConfig(feedConfig config.FeedConfig) error {
 	}
 	return nil
 }
+
+// ParseFile parses a local file in plain format (one IP/CIDR per line). An
+// empty or comment-only file is not an error and yields no prefixes, so a
+// placeholder file can be mounted before any entries are added.
+func ParseFile(path string) ([]netip.Prefix, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+
+	prefixes, report, err := parseLines(f)
+	logCorrections(path, report)
+	return prefixes, err
+}
@@ -1 +1,54 @@
 package parser
+
+import (
+	"net/netip"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{"entries", "# office\n192.0.2.1\n\n198.51.100.0/24 \n; vpn\n// lab\nnot-an-ip\n", []string{"192.0.2.1/32", "198.51.100.0/24"}},
+		{"empty", "", nil},
+		{"comments only", "# nothing allowlisted yet\n\n", nil},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "allowlist.txt")
+		if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		got, err := ParseFile(path)
+		if err != nil {
+			t.Errorf("%s: ParseFile error: %v", tt.name, err)
+			continue
+		}
+		want := make([]netip.Prefix, len(tt.want))
+		for i, c := range tt.want {
+			want[i] = netip.MustParsePrefix(c)
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("%s: ParseFile = %v, want %v", tt.name, got, want)
+		}
+	}
+}
+
+func TestParseFileMissing(t *testing.T) {
+	if _, err := ParseFile(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("ParseFile of a missing file succeeded")
+	}
+}
+
+func TestPlainParseBodyEmpty(t *testing.T) {
+	// A feed without entries most likely failed, unlike a local file
+	_, _, err := (&PlainParser{}).parseBody(strings.NewReader("# maintenance\n"))
+	if err == nil || !strings.Contains(err.Error(), "no valid IPs") {
+		t.Errorf("parseBody error = %v, want no valid IPs", err)
+	}
+}
@@ -2,50 +2,26 @@ package parser
 
 import (
 	"fmt"
-	"net"
-	"strings"
-)
-
-// parseIPOrCIDR parses a string as either an IP address or CIDR block
-func parseIPOrCIDR(s string) (net.IPNet, error) {
-	s = strings.TrimSpace(s)
-
-	// Try parsing as CIDR first
-	if strings.Contains(s, "/") {
-		_, ipnet, err := net.ParseCIDR(s)
-		if err != nil {
-			return net.IPNet{}, fmt.Errorf("invalid CIDR: %w", err)
-		}
-		return *ipnet, nil
-	}
+	"net/netip"
 
-	// Parse as IP address
-	ip := net.ParseIP(s)
-	if ip == nil {
-		return net.IPNet{}, fmt.Errorf("invalid IP address: %s", s)
-	}
+	"github.com/0x4272616E646F6E/unifi-threat-sync/internal/indicator"
+)
 
-	// Convert to CIDR with /32 for IPv4 or /128 for IPv6
-	var mask net.IPMask
-	if ip.To4() != nil {
-		mask = net.CIDRMask(32, 32)
-	} else {
-		mask = net.CIDRMask(128, 128)
+// logCorrections logs the corrections made while parsing a feed or file
+func logCorrections(name string, report indicator.Report) {
+	if !report.Empty() {
+		fmt.Printf("  %s: corrected entries: %s\n", name, report)
 	}
-
-	return net.IPNet{
-		IP:   ip,
-		Mask: mask,
-	}, nil
 }
 
 // isValidIP checks if a string is a valid IP address
 func isValidIP(s string) bool {
-	return net.ParseIP(s) != nil
+	_, err := netip.ParseAddr(s)
+	return err == nil
 }
 
 // isValidCIDR checks if a string is a valid CIDR block
 func isValidCIDR(s string) bool {
-	_, _, err := net.ParseCIDR(s)
+	_, err := netip.ParsePrefix(s)
 	return err == nil
 }
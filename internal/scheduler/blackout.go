@@ -0,0 +1,79 @@
+package scheduler
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/0x4272616E646F6E/unifi-threat-sync/internal/config"
+)
+
+// blackoutWindow is a recurring daily period during which changes must not
+// be pushed to the controller. Windows whose end is not after their start
+// wrap past midnight into the following day.
+type blackoutWindow struct {
+	days  [7]bool // indexed by time.Weekday of the day the window starts
+	start time.Duration
+	end   time.Duration
+}
+
+// parseBlackouts converts configured blackout windows into their runtime form
+func parseBlackouts(windows []config.BlackoutWindow) ([]blackoutWindow, error) {
+	result := make([]blackoutWindow, 0, len(windows))
+	for i, w := range windows {
+		start, err := parseClock(w.Start)
+		if err != nil {
+			return nil, fmt.Errorf("window %d start: %w", i, err)
+		}
+		end, err := parseClock(w.End)
+		if err != nil {
+			return nil, fmt.Errorf("window %d end: %w", i, err)
+		}
+
+		bw := blackoutWindow{start: start, end: end}
+		if len(w.Days) == 0 {
+			for d := range bw.days {
+				bw.days[d] = true
+			}
+		}
+		for _, day := range w.Days {
+			d, ok := dayNames[strings.ToLower(day)[:min(3, len(day))]]
+			if !ok {
+				return nil, fmt.Errorf("window %d: unknown day %q", i, day)
+			}
+			bw.days[d] = true
+		}
+
+		result = append(result, bw)
+	}
+	return result, nil
+}
+
+// parseClock parses a HH:MM time of day into an offset from midnight
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q (want HH:MM)", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// contains reports whether t falls inside the window. The offset is taken
+// from the wall clock rather than elapsed time since midnight, so windows
+// keep their local times on days with a DST change.
+func (w blackoutWindow) contains(t time.Time) bool {
+	offset := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute +
+		time.Duration(t.Second())*time.Second
+
+	if w.end > w.start {
+		return w.days[t.Weekday()] && offset >= w.start && offset < w.end
+	}
+
+	// Wrapping window: the late part belongs to today's window, the early
+	// part to the window that started yesterday
+	if offset >= w.start {
+		return w.days[t.Weekday()]
+	}
+	yesterday := (t.Weekday() + 6) % 7
+	return offset < w.end && w.days[yesterday]
+}
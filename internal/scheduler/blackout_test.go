@@ -0,0 +1,88 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/0x4272616E646F6E/unifi-threat-sync/internal/config"
+)
+
+func TestParseBlackoutsErrors(t *testing.T) {
+	tests := []config.BlackoutWindow{
+		{Start: "25:00", End: "03:00"},
+		{Start: "01:00", End: "3pm"},
+		{Start: "01:00", End: "03:00", Days: []string{"someday"}},
+	}
+	for _, w := range tests {
+		if _, err := parseBlackouts([]config.BlackoutWindow{w}); err == nil {
+			t.Errorf("parseBlackouts(%+v) succeeded, want error", w)
+		}
+	}
+}
+
+func TestBlackoutContains(t *testing.T) {
+	// 2026-10-16 is a Friday
+	tests := []struct {
+		name   string
+		window config.BlackoutWindow
+		at     string
+		want   bool
+	}{
+		{"before", config.BlackoutWindow{Start: "01:00", End: "03:00"}, "2026-10-16 00:59:59", false},
+		{"at start", config.BlackoutWindow{Start: "01:00", End: "03:00"}, "2026-10-16 01:00:00", true},
+		{"inside", config.BlackoutWindow{Start: "01:00", End: "03:00"}, "2026-10-16 02:59:59", true},
+		{"at end", config.BlackoutWindow{Start: "01:00", End: "03:00"}, "2026-10-16 03:00:00", false},
+		{"listed day", config.BlackoutWindow{Start: "01:00", End: "03:00", Days: []string{"Friday"}}, "2026-10-16 02:00:00", true},
+		{"other day", config.BlackoutWindow{Start: "01:00", End: "03:00", Days: []string{"sat", "sun"}}, "2026-10-16 02:00:00", false},
+
+		// 22:00-06:00 starting Friday covers Friday night and Saturday morning
+		{"wrap late part", config.BlackoutWindow{Start: "22:00", End: "06:00", Days: []string{"fri"}}, "2026-10-16 23:30:00", true},
+		{"wrap after midnight", config.BlackoutWindow{Start: "22:00", End: "06:00", Days: []string{"fri"}}, "2026-10-17 05:59:00", true},
+		{"wrap at end", config.BlackoutWindow{Start: "22:00", End: "06:00", Days: []string{"fri"}}, "2026-10-17 06:00:00", false},
+		{"wrap gap", config.BlackoutWindow{Start: "22:00", End: "06:00", Days: []string{"fri"}}, "2026-10-16 12:00:00", false},
+		{"wrap early part of start day", config.BlackoutWindow{Start: "22:00", End: "06:00", Days: []string{"fri"}}, "2026-10-16 03:00:00", false},
+		{"wrap late part of next day", config.BlackoutWindow{Start: "22:00", End: "06:00", Days: []string{"fri"}}, "2026-10-17 23:00:00", false},
+		{"wrap sunday into monday", config.BlackoutWindow{Start: "23:00", End: "01:00", Days: []string{"sun"}}, "2026-10-19 00:30:00", true},
+		{"whole day", config.BlackoutWindow{Start: "00:00", End: "00:00"}, "2026-10-16 12:00:00", true},
+	}
+	for _, tt := range tests {
+		windows, err := parseBlackouts([]config.BlackoutWindow{tt.window})
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got := windows[0].contains(mustTime(t, tt.at)); got != tt.want {
+			t.Errorf("%s: contains(%s) = %v, want %v", tt.name, tt.at, got, tt.want)
+		}
+	}
+}
+
+func TestBlackoutContainsDST(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		window config.BlackoutWindow
+		at     time.Time
+		want   bool
+	}{
+		// Clocks skip 02:00-03:00 on 2026-03-08, so 05:30 is only 4.5h after midnight
+		{"spring forward after end", config.BlackoutWindow{Start: "01:00", End: "05:00"}, time.Date(2026, 3, 8, 5, 30, 0, 0, loc), false},
+		{"spring forward inside", config.BlackoutWindow{Start: "01:00", End: "05:00"}, time.Date(2026, 3, 8, 4, 30, 0, 0, loc), true},
+		// Clocks repeat 01:00-02:00 on 2026-11-01, so 03:30 is 4.5h after midnight
+		{"fall back inside", config.BlackoutWindow{Start: "03:00", End: "04:00"}, time.Date(2026, 11, 1, 3, 30, 0, 0, loc), true},
+		{"fall back after end", config.BlackoutWindow{Start: "03:00", End: "04:00"}, time.Date(2026, 11, 1, 4, 0, 0, 0, loc), false},
+	}
+	for _, tt := range tests {
+		windows, err := parseBlackouts([]config.BlackoutWindow{tt.window})
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got := windows[0].contains(tt.at); got != tt.want {
+			t.Errorf("%s: contains(%s) = %v, want %v", tt.name, tt.at, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package scheduler
+
+import "time"
+
+// Clock abstracts time so schedules can be driven by a fake clock in tests
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// realClock is the Clock backed by the time package
+type realClock struct{}
+
+// Now returns the current local time
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// After waits for the duration to elapse and then sends the current time
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
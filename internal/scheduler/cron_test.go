@@ -0,0 +1,91 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCronErrors(t *testing.T) {
+	tests := []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * 32 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"*/x * * * *",
+		"5-1 * * * *",
+		"x * * * *",
+		"* * * foo *",
+		"@never",
+	}
+	for _, expr := range tests {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("ParseCron(%q) succeeded, want error", expr)
+		}
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	tests := []struct {
+		expr string
+		from string
+		want string // empty when the expression never fires
+	}{
+		{"* * * * *", "2026-10-14 10:07:30", "2026-10-14 10:08:00"},
+		{"0 * * * *", "2026-10-14 10:07:00", "2026-10-14 11:00:00"},
+		{"0 * * * *", "2026-10-14 10:00:00", "2026-10-14 11:00:00"},
+		{"*/15 * * * *", "2026-10-14 10:07:00", "2026-10-14 10:15:00"},
+		{"*/15 * * * *", "2026-10-14 23:50:00", "2026-10-15 00:00:00"},
+		{"5-10/5 * * * *", "2026-10-14 10:06:00", "2026-10-14 10:10:00"},
+		{"0,30 9-17 * * *", "2026-10-14 17:45:00", "2026-10-15 09:00:00"},
+		{"30 2 * * *", "2026-12-31 10:00:00", "2027-01-01 02:30:00"},
+		{"0 0 1 * *", "2026-01-31 12:00:00", "2026-02-01 00:00:00"},
+		{"0 0 31 * *", "2026-04-01 00:00:00", "2026-05-31 00:00:00"},
+		{"0 0 * * 0", "2026-10-14 10:00:00", "2026-10-18 00:00:00"},
+		{"0 0 * * 7", "2026-10-14 10:00:00", "2026-10-18 00:00:00"},
+		{"0 0 * * mon-fri", "2026-10-17 10:00:00", "2026-10-19 00:00:00"},
+		{"0 0 1 jan,jul *", "2026-02-01 00:00:00", "2026-07-01 00:00:00"},
+		{"0 9 13 * fri", "2026-10-01 00:00:00", "2026-10-02 09:00:00"},
+		{"0 9 13 * fri", "2026-10-10 00:00:00", "2026-10-13 09:00:00"},
+		{"0 0 13 * *", "2026-10-10 00:00:00", "2026-10-13 00:00:00"},
+		{"0 0 29 2 *", "2026-03-01 00:00:00", "2028-02-29 00:00:00"},
+		{"0 0 30 2 *", "2026-03-01 00:00:00", ""},
+		{"@hourly", "2026-10-14 10:07:00", "2026-10-14 11:00:00"},
+		{"@daily", "2026-10-14 10:07:00", "2026-10-15 00:00:00"},
+		{"@weekly", "2026-10-14 10:07:00", "2026-10-18 00:00:00"},
+		{"@monthly", "2026-10-14 10:07:00", "2026-11-01 00:00:00"},
+		{"@yearly", "2026-10-14 10:07:00", "2027-01-01 00:00:00"},
+	}
+	for _, tt := range tests {
+		cs, err := ParseCron(tt.expr)
+		if err != nil {
+			t.Errorf("ParseCron(%q): %v", tt.expr, err)
+			continue
+		}
+		got := cs.Next(mustTime(t, tt.from))
+		if tt.want == "" {
+			if !got.IsZero() {
+				t.Errorf("%q after %s = %s, want never", tt.expr, tt.from, got)
+			}
+			continue
+		}
+		if want := mustTime(t, tt.want); !got.Equal(want) {
+			t.Errorf("%q after %s = %s, want %s", tt.expr, tt.from, got, want)
+		}
+	}
+}
+
+// mustTime parses a "2006-01-02 15:04:05" time in UTC
+func mustTime(t *testing.T, s string) time.Time {
+	t.Helper()
+	v, err := time.Parse(time.DateTime, s)
+	if err != nil {
+		t.Fatalf("bad test time %q: %v", s, err)
+	}
+	return v
+}
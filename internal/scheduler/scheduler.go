@@ -0,0 +1,145 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/0x4272616E646F6E/unifi-threat-sync/internal/config"
+)
+
+// Scheduler decides when sync runs happen and whether changes may be pushed
+type Scheduler struct {
+	interval  time.Duration
+	cron      *CronSchedule
+	cronExpr  string
+	jitter    time.Duration
+	blackouts []blackoutWindow
+	clock     Clock
+	randN     func(n int64) int64
+	trigger   chan struct{}
+}
+
+// New creates a Scheduler from sync settings using the system clock
+func New(cfg config.SyncConfig) (*Scheduler, error) {
+	return NewWithClock(cfg, realClock{})
+}
+
+// NewWithClock creates a Scheduler driven by the given clock
+func NewWithClock(cfg config.SyncConfig, clock Clock) (*Scheduler, error) {
+	s := &Scheduler{
+		interval: cfg.Interval,
+		jitter:   cfg.Jitter,
+		clock:    clock,
+		randN:    rand.Int64N,
+		trigger:  make(chan struct{}, 1),
+	}
+
+	if cfg.Cron != "" {
+		cs, err := ParseCron(cfg.Cron)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sync.cron: %w", err)
+		}
+		s.cron = cs
+		s.cronExpr = cfg.Cron
+	}
+
+	blackouts, err := parseBlackouts(cfg.Blackouts)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sync.blackouts: %w", err)
+	}
+	s.blackouts = blackouts
+
+	return s, nil
+}
+
+// Reconfigure replaces the schedule with new sync settings, keeping the clock
+// and any pending trigger. It must not be called concurrently with Next or
+// Blocked, so call it from the function passed to Run.
+func (s *Scheduler) Reconfigure(cfg config.SyncConfig) error {
+	next, err := NewWithClock(cfg, s.clock)
+	if err != nil {
+		return err
+	}
+	s.interval = next.interval
+	s.cron = next.cron
+	s.cronExpr = next.cronExpr
+	s.jitter = next.jitter
+	s.blackouts = next.blackouts
+	return nil
+}
+
+// String describes the schedule for logging
+func (s *Scheduler) String() string {
+	desc := fmt.Sprintf("every %s", s.interval)
+	if s.cron != nil {
+		desc = fmt.Sprintf("cron %q", s.cronExpr)
+	}
+	if s.jitter > 0 {
+		desc += fmt.Sprintf(" (jitter up to %s)", s.jitter)
+	}
+	return desc
+}
+
+// Next returns the time of the next run after now, including any jitter
+func (s *Scheduler) Next(now time.Time) time.Time {
+	var next time.Time
+	if s.cron != nil {
+		next = s.cron.Next(now)
+		if next.IsZero() {
+			// Expression never fires again; fall back to the interval
+			next = now.Add(s.interval)
+		}
+	} else {
+		next = now.Add(s.interval)
+	}
+
+	if s.jitter > 0 {
+		next = next.Add(time.Duration(s.randN(int64(s.jitter))))
+	}
+	return next
+}
+
+// InBlackout reports whether t falls inside any configured blackout window
+func (s *Scheduler) InBlackout(t time.Time) bool {
+	for _, w := range s.blackouts {
+		if w.contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// Blocked reports whether pushes are currently frozen by a blackout window
+func (s *Scheduler) Blocked() bool {
+	return s.InBlackout(s.clock.Now())
+}
+
+// Trigger requests an immediate run. Requests made while one is already
+// pending are coalesced.
+func (s *Scheduler) Trigger() {
+	select {
+	case s.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// Run calls fn at each scheduled time, and whenever Trigger is called, until
+// ctx is cancelled
+func (s *Scheduler) Run(ctx context.Context, fn func(ctx context.Context)) {
+	for {
+		now := s.clock.Now()
+		next := s.Next(now)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.clock.After(next.Sub(now)):
+		case <-s.trigger:
+			fmt.Println("On-demand sync requested")
+		}
+
+		fn(ctx)
+	}
+}
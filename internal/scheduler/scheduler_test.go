@@ -0,0 +1,170 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/0x4272616E646F6E/unifi-threat-sync/internal/config"
+)
+
+// fakeClock is a Clock whose time only moves when a timer is fired
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers chan fakeTimer
+}
+
+// fakeTimer is a pending After call
+type fakeTimer struct {
+	d time.Duration
+	c chan time.Time
+}
+
+func newFakeClock(now time.Time) *fakeClock {
+	return &fakeClock{now: now, timers: make(chan fakeTimer, 1)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	timer := fakeTimer{d: d, c: make(chan time.Time, 1)}
+	c.timers <- timer
+	return timer.c
+}
+
+// fire advances the clock to the timer's deadline and fires it
+func (c *fakeClock) fire(timer fakeTimer) {
+	c.mu.Lock()
+	c.now = c.now.Add(timer.d)
+	now := c.now
+	c.mu.Unlock()
+	timer.c <- now
+}
+
+// receive returns the next value from ch, failing the test if none arrives
+func receive[T any](t *testing.T, ch <-chan T) T {
+	t.Helper()
+	select {
+	case v := <-ch:
+		return v
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for scheduler")
+		var zero T
+		return zero
+	}
+}
+
+func TestSchedulerNext(t *testing.T) {
+	now := mustTime(t, "2026-10-14 10:07:00")
+	tests := []struct {
+		name string
+		cfg  config.SyncConfig
+		want string
+	}{
+		{"interval", config.SyncConfig{Interval: time.Hour}, "2026-10-14 11:07:00"},
+		{"cron", config.SyncConfig{Interval: time.Hour, Cron: "*/15 * * * *"}, "2026-10-14 10:15:00"},
+		{"cron never fires", config.SyncConfig{Interval: time.Hour, Cron: "0 0 30 2 *"}, "2026-10-14 11:07:00"},
+		{"interval with jitter", config.SyncConfig{Interval: time.Hour, Jitter: 10 * time.Minute}, "2026-10-14 11:12:00"},
+		{"cron with jitter", config.SyncConfig{Cron: "@hourly", Jitter: 10 * time.Minute}, "2026-10-14 11:05:00"},
+	}
+	for _, tt := range tests {
+		s, err := NewWithClock(tt.cfg, newFakeClock(now))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		s.randN = func(n int64) int64 { return n / 2 }
+		if got, want := s.Next(now), mustTime(t, tt.want); !got.Equal(want) {
+			t.Errorf("%s: Next = %s, want %s", tt.name, got, want)
+		}
+	}
+}
+
+func TestSchedulerBlocked(t *testing.T) {
+	cfg := config.SyncConfig{
+		Interval:  time.Hour,
+		Blackouts: []config.BlackoutWindow{{Start: "01:00", End: "03:00"}},
+	}
+	clock := newFakeClock(mustTime(t, "2026-10-14 00:30:00"))
+	s, err := NewWithClock(cfg, clock)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Blocked() {
+		t.Error("Blocked before the window")
+	}
+	clock.fire(fakeTimer{d: time.Hour, c: make(chan time.Time, 1)})
+	if !s.Blocked() {
+		t.Error("not Blocked inside the window")
+	}
+	clock.fire(fakeTimer{d: 2 * time.Hour, c: make(chan time.Time, 1)})
+	if s.Blocked() {
+		t.Error("Blocked after the window")
+	}
+}
+
+func TestSchedulerTriggerCoalesces(t *testing.T) {
+	s, err := NewWithClock(config.SyncConfig{Interval: time.Hour}, newFakeClock(time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Trigger()
+	s.Trigger()
+	if n := len(s.trigger); n != 1 {
+		t.Errorf("%d pending triggers, want 1", n)
+	}
+}
+
+func TestSchedulerRun(t *testing.T) {
+	start := mustTime(t, "2026-10-14 10:07:00")
+	clock := newFakeClock(start)
+	s, err := NewWithClock(config.SyncConfig{Interval: 10 * time.Minute}, clock)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ran := make(chan time.Time)
+	done := make(chan struct{})
+	go func() {
+		s.Run(ctx, func(ctx context.Context) { ran <- clock.Now() })
+		close(done)
+	}()
+
+	// Scheduled run
+	timer := receive(t, clock.timers)
+	if timer.d != 10*time.Minute {
+		t.Fatalf("waited %s, want 10m", timer.d)
+	}
+	clock.fire(timer)
+	if got := receive(t, ran); !got.Equal(start.Add(10 * time.Minute)) {
+		t.Errorf("scheduled run at %s, want %s", got, start.Add(10*time.Minute))
+	}
+
+	// Triggered runs happen without waiting for the timer
+	receive(t, clock.timers)
+	s.Trigger()
+	if got := receive(t, ran); !got.Equal(start.Add(10 * time.Minute)) {
+		t.Errorf("triggered run at %s, want %s", got, start.Add(10*time.Minute))
+	}
+	receive(t, clock.timers)
+
+	// Reconfiguring between runs changes the next wait
+	if err := s.Reconfigure(config.SyncConfig{Cron: "*/15 * * * *"}); err != nil {
+		t.Fatal(err)
+	}
+	s.Trigger()
+	receive(t, ran)
+	if timer := receive(t, clock.timers); timer.d != 13*time.Minute {
+		t.Errorf("waited %s after reconfigure, want 13m", timer.d)
+	}
+
+	cancel()
+	receive(t, done)
+}
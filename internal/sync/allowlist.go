@@ -0,0 +1,63 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+
+	"github.com/0x4272616E646F6E/unifi-threat-sync/internal/normalizer"
+	"github.com/0x4272616E646F6E/unifi-threat-sync/internal/parser"
+)
+
+// loadAllowlist collects static, file and feed allowlist entries. Allowlist
+// feeds fall back to their last good result; a feed that has never been
+// fetched fails the sync so protected ranges are never pushed unprotected.
+func (s *Syncer) loadAllowlist(ctx context.Context) ([]netip.Prefix, error) {
+	cfg := s.config.Allowlist
+
+	allowed, err := normalizer.FromStrings(cfg.CIDRs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid allowlist entry: %w", err)
+	}
+
+	for _, path := range cfg.Files {
+		prefixes, err := parser.ParseFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load allowlist file %s: %w", path, err)
+		}
+		allowed = append(allowed, prefixes...)
+	}
+
+	feeds := cfg.Feeds.GetEnabled()
+	if len(feeds) > 0 {
+		sources, err := s.fetchFeeds(ctx, feeds, false)
+		if err != nil {
+			return nil, err
+		}
+		for _, feedConfig := range feeds {
+			if state := s.feedStates[feedConfig.Name]; state == nil || state.lastFetch.IsZero() {
+				return nil, fmt.Errorf("allowlist feed %s has never been fetched successfully", feedConfig.Name)
+			}
+		}
+		allowed = append(allowed, normalizer.Flatten(sources)...)
+	}
+
+	return normalizer.Normalize(allowed), nil
+}
+
+// applyAllowlist subtracts allowlisted ranges from the blocklist and reports
+// every blocked prefix that was removed or split
+func applyAllowlist(prefixes, allowed []netip.Prefix) []netip.Prefix {
+	if len(allowed) == 0 {
+		return prefixes
+	}
+
+	result, collisions := normalizer.Subtract(prefixes, allowed)
+	for _, c := range collisions {
+		fmt.Printf("  Allowlist: %s removed from blocked %s\n", c.Allowed.String(), c.Blocked.String())
+	}
+	fmt.Printf("Allowlist: %d entries, %d collisions, %d prefixes after subtraction\n",
+		len(allowed), len(collisions), len(result))
+
+	return result
+}
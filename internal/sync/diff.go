@@ -1 +1,45 @@
 package sync
+
+import (
+	"math/big"
+	"sort"
+
+	"github.com/0x4272616E646F6E/unifi-threat-sync/internal/normalizer"
+)
+
+// Diff returns the members added and removed when going from old to new.
+// Both results are sorted.
+func Diff(old, new []string) (added, removed []string) {
+	oldSet := make(map[string]bool, len(old))
+	for _, m := range old {
+		oldSet[m] = true
+	}
+	newSet := make(map[string]bool, len(new))
+	for _, m := range new {
+		newSet[m] = true
+		if !oldSet[m] {
+			added = append(added, m)
+		}
+	}
+	for _, m := range old {
+		if !newSet[m] {
+			removed = append(removed, m)
+		}
+	}
+
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
+
+// AddressDiff returns the number of addresses added and removed when going
+// from old to new. Unlike Diff it compares the addresses the members cover,
+// so a range listed with different prefix lengths is not a change. Members
+// that don't parse are ignored.
+func AddressDiff(old, new []string) (added, removed *big.Int) {
+	oldPrefixes, _ := normalizer.FromStrings(old)
+	newPrefixes, _ := normalizer.FromStrings(new)
+	added = normalizer.AddressCount(normalizer.Difference(newPrefixes, oldPrefixes))
+	removed = normalizer.AddressCount(normalizer.Difference(oldPrefixes, newPrefixes))
+	return added, removed
+}
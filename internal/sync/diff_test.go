@@ -0,0 +1,56 @@
+package sync
+
+import (
+	"math/big"
+	"slices"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name           string
+		old, new       []string
+		added, removed []string
+	}{
+		{"empty", nil, nil, nil, nil},
+		{"unchanged", []string{"10.0.0.0/8", "192.0.2.1"}, []string{"192.0.2.1", "10.0.0.0/8"}, nil, nil},
+		{"all added", nil, []string{"192.0.2.1", "10.0.0.0/8"}, []string{"10.0.0.0/8", "192.0.2.1"}, nil},
+		{"all removed", []string{"192.0.2.1", "10.0.0.0/8"}, nil, nil, []string{"10.0.0.0/8", "192.0.2.1"}},
+		{
+			"both sorted",
+			[]string{"198.51.100.0/24", "10.0.0.0/8", "192.0.2.1"},
+			[]string{"203.0.113.5", "192.0.2.1", "172.16.0.0/12"},
+			[]string{"172.16.0.0/12", "203.0.113.5"},
+			[]string{"10.0.0.0/8", "198.51.100.0/24"},
+		},
+		{"compares strings only", []string{"192.0.2.0/24"}, []string{"192.0.2.0/25", "192.0.2.128/25"}, []string{"192.0.2.0/25", "192.0.2.128/25"}, []string{"192.0.2.0/24"}},
+	}
+	for _, tt := range tests {
+		added, removed := Diff(tt.old, tt.new)
+		if !slices.Equal(added, tt.added) || !slices.Equal(removed, tt.removed) {
+			t.Errorf("%s: Diff = %v, %v; want %v, %v", tt.name, added, removed, tt.added, tt.removed)
+		}
+	}
+}
+
+func TestAddressDiff(t *testing.T) {
+	tests := []struct {
+		name           string
+		old, new       []string
+		added, removed int64
+	}{
+		{"empty", nil, nil, 0, 0},
+		{"split range is not a change", []string{"192.0.2.0/24"}, []string{"192.0.2.0/25", "192.0.2.128/25"}, 0, 0},
+		{"covered member is not a change", []string{"10.0.0.0/8"}, []string{"10.0.0.0/8", "10.1.2.3"}, 0, 0},
+		{"widened", []string{"192.0.2.0/25"}, []string{"192.0.2.0/24"}, 128, 0},
+		{"narrowed", []string{"192.0.2.0/24"}, []string{"192.0.2.7"}, 0, 255},
+		{"replaced", []string{"192.0.2.0/24"}, []string{"198.51.100.0/30", "2001:db8::/126"}, 8, 256},
+		{"unparsable ignored", []string{"192.0.2.1", "not-an-ip"}, []string{"192.0.2.1", "also bad"}, 0, 0},
+	}
+	for _, tt := range tests {
+		added, removed := AddressDiff(tt.old, tt.new)
+		if added.Cmp(big.NewInt(tt.added)) != 0 || removed.Cmp(big.NewInt(tt.removed)) != 0 {
+			t.Errorf("%s: AddressDiff = %s, %s; want %d, %d", tt.name, added, removed, tt.added, tt.removed)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	gosync "sync"
+	"time"
+
+	"github.com/0x4272616E646F6E/unifi-threat-sync/internal/config"
+	"github.com/0x4272616E646F6E/unifi-threat-sync/internal/normalizer"
+	"github.com/0x4272616E646F6E/unifi-threat-sync/internal/parser"
+)
+
+// feedResult holds the outcome of fetching a single feed
+type feedResult struct {
+	prefixes []netip.Prefix
+	filtered int
+	rejected int
+	split    int
+	err      error
+}
+
+// feedState caches the last successful fetch of a feed between sync cycles
+type feedState struct {
+	prefixes    []netip.Prefix
+	filtered    int
+	rejected    int
+	split       int
+	lastFetch   time.Time
+	nextRefresh time.Time
+	lastErr     error
+}
+
+// due reports whether the feed should be refetched at the given time
+func (fs *feedState) due(now time.Time) bool {
+	return fs == nil || !now.Before(fs.nextRefresh)
+}
+
+// fetchAllFeeds returns the results of all enabled blocklist feeds
+func (s *Syncer) fetchAllFeeds(ctx context.Context) ([]normalizer.Source, error) {
+	return s.fetchFeeds(ctx, s.config.Feeds.GetEnabled(), true)
+}
+
+// fetchFeeds refreshes every feed whose interval has elapsed and returns the
+// cached results of all given feeds. Fetches run concurrently, bounded by
+// sync.concurrency, and results are returned in config order so output is
+// stable. Blocklist feeds are filtered by the prefix-length policy and the
+// reserved ranges filter; allowlist feeds are kept as listed.
+func (s *Syncer) fetchFeeds(ctx context.Context, enabledFeeds []config.FeedConfig, blocklist bool) ([]normalizer.Source, error) {
+	results := make([]*feedResult, len(enabledFeeds))
+	now := time.Now()
+
+	workers := s.config.Sync.Concurrency
+	if workers < 1 {
+		workers = 1
+	}
+	sem := make(chan struct{}, workers)
+
+	var wg gosync.WaitGroup
+	for i, feedConfig := range enabledFeeds {
+		if !s.feedStates[feedConfig.Name].due(now) {
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, feedConfig config.FeedConfig) {
+			defer wg.Done()
+
+			// Wait for a free worker slot or cancellation
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				results[i] = &feedResult{err: ctx.Err()}
+				return
+			}
+
+			result := s.fetchFeed(ctx, feedConfig, blocklist)
+			results[i] = &result
+		}(i, feedConfig)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	sources := make([]normalizer.Source, 0, len(enabledFeeds))
+	for i, feedConfig := range enabledFeeds {
+		state := s.updateFeedState(feedConfig, results[i], now)
+		if state.prefixes == nil {
+			continue
+		}
+		sources = append(sources, normalizer.Source{
+			Name:     feedConfig.Name,
+			Weight:   feedConfig.Weight,
+			Prefixes: state.prefixes,
+		})
+	}
+
+	return sources, nil
+}
+
+// updateFeedState folds a fetch result (nil if the feed was not due) into the
+// feed cache and returns the resulting state. A failed fetch keeps the last
+// good data and retries on the next cycle.
+func (s *Syncer) updateFeedState(feedConfig config.FeedConfig, result *feedResult, now time.Time) *feedState {
+	state := s.feedStates[feedConfig.Name]
+	if state == nil {
+		state = &feedState{}
+		s.feedStates[feedConfig.Name] = state
+	}
+
+	switch {
+	case result == nil:
+		fmt.Printf("  %s: using cached %d IPs/CIDRs (next refresh %s)\n",
+			feedConfig.Name, len(state.prefixes), state.nextRefresh.Format(time.RFC3339))
+	case result.err != nil:
+		fmt.Printf("  Warning: feed %s: %v, skipping\n", feedConfig.Name, result.err)
+		state.lastErr = result.err
+		state.nextRefresh = now
+		s.noteFeedFailure(feedConfig.Name)
+	default:
+		fmt.Printf("  %s: found %d IPs/CIDRs\n", feedConfig.Name, len(result.prefixes))
+		if result.filtered > 0 {
+			fmt.Printf("  %s: filtered %d reserved/bogon entries\n", feedConfig.Name, result.filtered)
+		}
+		if result.rejected > 0 || result.split > 0 {
+			fmt.Printf("  %s: %d entries rejected and %d split by the prefix-length policy\n",
+				feedConfig.Name, result.rejected, result.split)
+		}
+		state.prefixes = result.prefixes
+		state.filtered = result.filtered
+		state.rejected = result.rejected
+		state.split = result.split
+		state.lastFetch = now
+		state.nextRefresh = now.Add(feedConfig.Interval)
+		state.lastErr = nil
+	}
+
+	if s.healthRecorder != nil {
+		s.healthRecorder.RecordFeedStatus(feedConfig.Name, len(state.prefixes), state.filtered, state.rejected, state.split,
+			state.lastFetch, state.nextRefresh, state.lastErr)
+	}
+
+	return state
+}
+
+// fetchFeed fetches and parses a single feed, honoring its configured timeout.
+// Filters only apply to blocklist feeds, so allowlisted ranges are never
+// dropped.
+func (s *Syncer) fetchFeed(ctx context.Context, feedConfig config.FeedConfig, blocklist bool) feedResult {
+	fmt.Printf("Fetching feed: %s (%s)\n", feedConfig.Name, feedConfig.Parser)
+
+	// Get parser
+	p, err := parser.Get(feedConfig.Parser)
+	if err != nil {
+		return feedResult{err: err}
+	}
+
+	feedCtx, cancel := context.WithTimeout(ctx, feedConfig.GetTimeout())
+	defer cancel()
+
+	// Parse feed
+	prefixes, err := p.Parse(feedCtx, feedConfig)
+	if err != nil {
+		return feedResult{err: fmt.Errorf("failed to parse feed: %w", err)}
+	}
+	if !blocklist {
+		return feedResult{prefixes: prefixes}
+	}
+
+	// Reject (or split) entries broader than the prefix-length policy allows
+	prefixes, violations := normalizer.ApplyLengthPolicy(prefixes, s.lengthPolicy(feedConfig))
+	rejected, split := 0, 0
+	for _, v := range violations {
+		if v.Pieces > 0 {
+			fmt.Printf("  %s: split %s into %d prefixes, it is shorter than the minimum prefix length\n",
+				feedConfig.Name, v.Prefix, v.Pieces)
+			split++
+		} else {
+			fmt.Printf("  %s: rejected %s, it is shorter than the minimum prefix length\n", feedConfig.Name, v.Prefix)
+			rejected++
+		}
+	}
+
+	// Drop private, loopback, multicast and other special-purpose ranges
+	filtered := 0
+	if s.config.Filter.FilterReserved() {
+		prefixes, filtered = normalizer.FilterReserved(prefixes)
+	}
+
+	return feedResult{prefixes: prefixes, filtered: filtered, rejected: rejected, split: split}
+}
+
+// lengthPolicy returns the prefix-length policy for a feed
+func (s *Syncer) lengthPolicy(feedConfig config.FeedConfig) normalizer.LengthPolicy {
+	policy := s.config.Filter.PolicyFor(feedConfig)
+	return normalizer.LengthPolicy{
+		MinIPv4:  policy.MinIPv4,
+		MinIPv6:  policy.MinIPv6,
+		Split:    policy.Action == "split",
+		MaxSplit: policy.MaxSplit,
+	}
+}
@@ -0,0 +1,383 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/netip"
+	"slices"
+	"time"
+
+	"github.com/0x4272616E646F6E/unifi-threat-sync/internal/audit"
+	"github.com/0x4272616E646F6E/unifi-threat-sync/internal/config"
+	"github.com/0x4272616E646F6E/unifi-threat-sync/internal/manual"
+	"github.com/0x4272616E646F6E/unifi-threat-sync/internal/normalizer"
+	"github.com/0x4272616E646F6E/unifi-threat-sync/internal/notify"
+	"github.com/0x4272616E646F6E/unifi-threat-sync/internal/unifi"
+)
+
+// profileState holds what the syncer remembers about one output profile
+// between runs
+type profileState struct {
+	lastHash     string
+	prefixStates map[netip.Prefix]*prefixState
+	seeded       bool                       // The group has been read into seed
+	seed         *normalizer.Trie[struct{}] // Group members found when the state was fresh
+}
+
+// profile returns the state for the named profile, creating it if needed
+func (s *Syncer) profile(name string) *profileState {
+	ps, ok := s.profileStates[name]
+	if !ok {
+		ps = &profileState{prefixStates: make(map[netip.Prefix]*prefixState)}
+		s.profileStates[name] = ps
+	}
+	return ps
+}
+
+// selectSources returns the sources a profile draws from, in feed order
+func selectSources(profile config.ProfileConfig, sources []normalizer.Source) []normalizer.Source {
+	if len(profile.Feeds) == 0 {
+		return sources
+	}
+
+	wanted := make(map[string]bool, len(profile.Feeds))
+	for _, name := range profile.Feeds {
+		wanted[name] = true
+	}
+
+	selected := make([]normalizer.Source, 0, len(profile.Feeds))
+	for _, src := range sources {
+		if wanted[src.Name] {
+			selected = append(selected, src)
+		}
+	}
+	return selected
+}
+
+// syncProfile builds the blocklist for one profile and pushes it to the
+// profile's firewall group when it changed
+func (s *Syncer) syncProfile(ctx context.Context, profile config.ProfileConfig, sources []normalizer.Source, allowed []netip.Prefix) error {
+	state := s.profile(profile.Name)
+	sources = selectSources(profile, sources)
+
+	fmt.Printf("Building profile '%s' from %d feeds\n", profile.Name, len(sources))
+
+	// Normalize (deduplicate and sort) while keeping which feeds listed
+	// each prefix
+	entries := normalizer.NormalizeEntries(sources)
+
+	// Keep only prefixes with enough weighted feed agreement when a score
+	// threshold is set
+	var normalized []netip.Prefix
+	if threshold := s.config.Sync.ScoreThreshold; threshold > 0 {
+		normalized = normalizer.Consensus(sources, threshold)
+		fmt.Printf("After consensus scoring (threshold %g): %d unique IPs/CIDRs\n", threshold, len(normalized))
+	} else {
+		normalized = make([]netip.Prefix, len(entries))
+		for i, e := range entries {
+			normalized[i] = e.Prefix
+		}
+		fmt.Printf("After deduplication: %d unique IPs/CIDRs\n", len(normalized))
+	}
+
+	// A fresh state doesn't know what is already blocked, so take it from
+	// the group; otherwise minAge would empty the group after a restart
+	if !state.seeded && s.config.Sync.MinAge > 0 {
+		seed, err := s.groupPrefixes(ctx, profile)
+		if err != nil {
+			return err
+		}
+		state.seed = normalizer.NewTrie[struct{}](seed)
+		state.seeded = true
+	}
+
+	// Apply minimum age and grace period to damp feed churn
+	normalized = s.stabilize(state, normalized, time.Now())
+
+	// Merge manual entries; they bypass scoring and minimum age
+	if s.manualSource != nil {
+		active, err := s.manualSource.Active()
+		if err != nil {
+			fmt.Printf("Warning: pruning expired manual entries: %v\n", err)
+		}
+
+		// Entries stored before the filter settings changed are held to them too
+		policy := manual.PolicyFrom(s.config.Filter)
+		kept := active[:0:0]
+		for _, p := range active {
+			if err := policy.Check(p); err != nil {
+				fmt.Printf("Warning: skipping manual entry: %v\n", err)
+				continue
+			}
+			kept = append(kept, p)
+		}
+		if len(kept) > 0 {
+			normalized = normalizer.Merge(normalized, kept)
+			entries = append(entries, normalizer.NormalizeEntries([]normalizer.Source{{Name: ManualSourceName, Prefixes: kept}})...)
+			fmt.Printf("Merged %d manual entries\n", len(kept))
+		}
+	}
+
+	// Remove allowlisted ranges
+	normalized = applyAllowlist(normalized, allowed)
+
+	// Drop covered prefixes and merge siblings; the address space is unchanged
+	before := len(normalized)
+	normalized = normalizer.Aggregate(normalized)
+	fmt.Printf("After aggregation: %d prefixes (from %d)\n", len(normalized), before)
+
+	// Trade precision for size when the list is still over the target
+	if cfg := s.config.Sync.Summarize; cfg.Target > 0 {
+		merges, extra := 0, 0.0
+		if len(normalized) > cfg.Target {
+			// Never widen into ranges the filters keep out of the list
+			avoid := allowed
+			if s.config.Filter.FilterReserved() {
+				avoid = append(slices.Clone(allowed), normalizer.Reserved()...)
+			}
+			minLength := normalizer.LengthPolicy{
+				MinIPv4: s.config.Filter.Prefix.MinIPv4,
+				MinIPv6: s.config.Filter.Prefix.MinIPv6,
+			}
+			var summary normalizer.Summary
+			normalized, summary = normalizer.Summarize(normalized, avoid, minLength, cfg.Target, cfg.MaxWiden)
+			fmt.Printf("After summarization: %d prefixes (%d merges, %s extra addresses blocked)\n",
+				summary.After, summary.Merges, summary.ExtraAddresses)
+			if !summary.TargetReached {
+				fmt.Printf("Warning: could not summarize below %d prefixes within sync.summarize.maxWiden %d\n",
+					cfg.Target, cfg.MaxWiden)
+			}
+			merges = summary.Merges
+			extra, _ = new(big.Float).SetInt(summary.ExtraAddresses).Float64()
+		}
+		if s.healthRecorder != nil {
+			s.healthRecorder.RecordSummary(profile.GroupName, merges, extra)
+		}
+	}
+
+	// Calculate hash of normalized list
+	currentHash := s.calculateHash(normalized)
+
+	// Check if update is needed
+	if currentHash == state.lastHash {
+		fmt.Println("No changes detected, skipping update")
+		s.publish(profile, state, normalized, normalizer.NewIndex(entries))
+		return nil
+	}
+
+	// Convert to strings for UniFi API
+	members := normalizer.ToStrings(normalized)
+
+	// A fresh state has no hash to compare with, but the group may already
+	// hold the list, e.g. after a restart or in -once mode
+	if state.lastHash == "" {
+		group, err := s.unifiClient.GetFirewallGroup(ctx, profile.GroupName)
+		if err != nil && !errors.Is(err, unifi.ErrNotFound) {
+			return fmt.Errorf("failed to get firewall group: %w", controllerError{err})
+		}
+		if err == nil {
+			if missing, unexpected := Diff(canonicalMembers(group.Members), members); len(missing) == 0 && len(unexpected) == 0 {
+				fmt.Printf("Group '%s' already up to date, skipping update\n", profile.GroupName)
+				if s.pushGate == nil || !s.pushGate.Blocked() {
+					if err := s.ensureRule(ctx, profile, group.ID); err != nil {
+						return err
+					}
+				}
+				state.lastHash = currentHash
+				s.publish(profile, state, normalized, normalizer.NewIndex(entries))
+				return nil
+			}
+		}
+	}
+
+	// Leave lastHash untouched so the change is pushed once the window ends
+	if s.pushGate != nil && s.pushGate.Blocked() {
+		fmt.Printf("Changes detected (%d entries) but blackout window is active, not pushing\n", len(normalized))
+		s.noteHeld()
+		return nil
+	}
+
+	fmt.Println("Changes detected, updating UniFi...")
+
+	// Get or create firewall group
+	group, err := s.unifiClient.GetFirewallGroup(ctx, profile.GroupName)
+	if err != nil {
+		if err := s.checkSafety(profile, members, nil); err != nil {
+			return err
+		}
+		fmt.Printf("Group '%s' not found, creating...\n", profile.GroupName)
+		group, err = s.unifiClient.CreateFirewallGroup(ctx, profile.GroupName, members)
+		s.recordChange(ctx, profile, "create", members, nil, sources, err)
+		if err != nil {
+			return fmt.Errorf("failed to create firewall group: %w", controllerError{err})
+		}
+		fmt.Printf("Created firewall group '%s'\n", profile.GroupName)
+	} else {
+		if err := s.checkSafety(profile, members, group.Members); err != nil {
+			return err
+		}
+		// Update existing group
+		fmt.Printf("Updating firewall group '%s'...\n", profile.GroupName)
+		err := s.unifiClient.UpdateFirewallGroup(ctx, group.ID, members)
+		s.recordChange(ctx, profile, "update", members, group.Members, sources, err)
+		if err != nil {
+			return fmt.Errorf("failed to update firewall group: %w", controllerError{err})
+		}
+		fmt.Printf("Updated firewall group '%s'\n", profile.GroupName)
+	}
+
+	// Check the controller stored everything that was sent
+	if err := s.verifyGroup(ctx, profile, members, sources); err != nil {
+		return err
+	}
+
+	// Make sure the group is actually enforced
+	if err := s.ensureRule(ctx, profile, group.ID); err != nil {
+		return err
+	}
+
+	// Update last hash and remember what was pushed
+	state.lastHash = currentHash
+	s.noteChange()
+	s.publish(profile, state, normalized, normalizer.NewIndex(entries))
+
+	return nil
+}
+
+// groupPrefixes returns the prefixes currently in the profile's group, or
+// none when the group doesn't exist yet. Members that don't parse are skipped.
+func (s *Syncer) groupPrefixes(ctx context.Context, profile config.ProfileConfig) ([]netip.Prefix, error) {
+	group, err := s.unifiClient.GetFirewallGroup(ctx, profile.GroupName)
+	if errors.Is(err, unifi.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get firewall group: %w", controllerError{err})
+	}
+	prefixes, _ := normalizer.FromStrings(group.Members)
+	return prefixes, nil
+}
+
+// ensureRule creates the profile's drop rule for its group in the configured
+// ruleset and index, or rewrites it when it no longer matches
+func (s *Syncer) ensureRule(ctx context.Context, profile config.ProfileConfig, groupID string) error {
+	want := unifi.NewDropRule(profile.GroupName, profile.Ruleset, profile.RuleIndex, groupID)
+
+	rule, err := s.unifiClient.GetFirewallRule(ctx, want.Name)
+	if err != nil && !errors.Is(err, unifi.ErrNotFound) {
+		return fmt.Errorf("failed to get firewall rule: %w", controllerError{err})
+	}
+
+	if rule == nil {
+		fmt.Printf("Rule '%s' not found, creating in %s at index %d...\n", want.Name, want.Ruleset, want.RuleIndex)
+		_, err := s.unifiClient.CreateFirewallRule(ctx, want)
+		s.recordRule(profile, "create-rule", err)
+		if err != nil {
+			return fmt.Errorf("failed to create firewall rule: %w", controllerError{err})
+		}
+		return nil
+	}
+
+	if rule.Matches(want) {
+		return nil
+	}
+	fmt.Printf("Rule '%s' differs from profile '%s', updating...\n", want.Name, profile.Name)
+	err = s.unifiClient.UpdateFirewallRule(ctx, rule.ID, want)
+	s.recordRule(profile, "update-rule", err)
+	if err != nil {
+		return fmt.Errorf("failed to update firewall rule: %w", controllerError{err})
+	}
+	return nil
+}
+
+// recordRule writes an audit record for a firewall rule mutation
+func (s *Syncer) recordRule(profile config.ProfileConfig, action string, pushErr error) {
+	if s.auditLog == nil {
+		return
+	}
+	rec := audit.Record{
+		Action:     action,
+		Site:       s.config.UniFi.Site,
+		Profile:    profile.Name,
+		Group:      profile.GroupName,
+		ConfigHash: s.config.Hash(),
+	}
+	if pushErr != nil {
+		rec.Error = pushErr.Error()
+	}
+	if err := s.auditLog.Write(rec); err != nil {
+		fmt.Printf("Warning: failed to write audit record: %v\n", err)
+	}
+}
+
+// checkSafety aborts a push that would exceed the configured safety limits
+func (s *Syncer) checkSafety(profile config.ProfileConfig, members, current []string) error {
+	if max := s.config.Sync.MaxEntries; max > 0 && len(members) > max {
+		return fmt.Errorf("%w: %d entries exceeds sync.maxEntries %d", ErrSafetyLimit, len(members), max)
+	}
+
+	if pct := s.config.Sync.MaxRemovePercent; pct > 0 && len(current) > 0 {
+		_, removed := Diff(current, members)
+		if share := float64(len(removed)) * 100 / float64(len(current)); share > pct {
+			return fmt.Errorf("%w: removing %d of %d entries (%.1f%%) exceeds sync.maxRemovePercent %g",
+				ErrSafetyLimit, len(removed), len(current), share, pct)
+		}
+	}
+
+	return nil
+}
+
+// recordChange writes an audit record for a controller mutation and, when it
+// succeeded and added or removed at least notify.largeChange entries, sends a
+// large-change notification
+func (s *Syncer) recordChange(ctx context.Context, profile config.ProfileConfig, action string, members, previous []string, sources []normalizer.Source, pushErr error) {
+	added, removed := Diff(previous, members)
+	addedAddresses, removedAddresses := AddressDiff(previous, members)
+
+	if s.auditLog != nil {
+		feeds := make([]string, 0, len(sources))
+		for _, src := range sources {
+			feeds = append(feeds, src.Name)
+		}
+		rec := audit.Record{
+			Action:       action,
+			Site:         s.config.UniFi.Site,
+			Profile:      profile.Name,
+			Group:        profile.GroupName,
+			AddedCount:   len(added),
+			RemovedCount: len(removed),
+			Added:        added,
+			Removed:      removed,
+			AddedAddrs:   addedAddresses.String(),
+			RemovedAddrs: removedAddresses.String(),
+			Feeds:        feeds,
+			ConfigHash:   s.config.Hash(),
+		}
+		if pushErr != nil {
+			rec.Error = pushErr.Error()
+		}
+		if err := s.auditLog.Write(rec); err != nil {
+			fmt.Printf("Warning: failed to write audit record: %v\n", err)
+		}
+	}
+
+	if pushErr != nil {
+		return
+	}
+	fmt.Printf("Group '%s': %d added, %d removed (%s addresses added, %s removed)\n",
+		profile.GroupName, len(added), len(removed), addedAddresses, removedAddresses)
+
+	if s.notifier == nil || len(added)+len(removed) < s.config.Notify.LargeChangeThreshold() {
+		return
+	}
+	s.notifier.Notify(ctx, notify.Event{
+		Type:    notify.EventLargeChange,
+		Profile: profile.Name,
+		Group:   profile.GroupName,
+		Message: fmt.Sprintf("Large change pushed to group '%s'", profile.GroupName),
+		Added:   len(added),
+		Removed: len(removed),
+	})
+}
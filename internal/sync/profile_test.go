@@ -0,0 +1,244 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"slices"
+	gosync "sync"
+	"testing"
+	"time"
+
+	"github.com/0x4272616E646F6E/unifi-threat-sync/internal/config"
+	"github.com/0x4272616E646F6E/unifi-threat-sync/internal/normalizer"
+	"github.com/0x4272616E646F6E/unifi-threat-sync/internal/notify"
+	"github.com/0x4272616E646F6E/unifi-threat-sync/internal/unifi"
+)
+
+// fakeController is a UniFi controller holding firewall groups and rules in
+// memory
+type fakeController struct {
+	mu      gosync.Mutex
+	groups  map[string]*unifi.FirewallGroup // By ID
+	rules   []unifi.FirewallRule
+	updates int // Group creates and updates
+}
+
+func (fc *fakeController) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	const groups, rules = "/proxy/network/api/s/default/rest/firewallgroup", "/proxy/network/api/s/default/rest/firewallrule"
+	var data interface{}
+	switch {
+	case r.URL.Path == "/api/auth/login":
+	case r.URL.Path == groups && r.Method == http.MethodGet:
+		list := []unifi.FirewallGroup{}
+		for _, g := range fc.groups {
+			list = append(list, *g)
+		}
+		data = list
+	case r.URL.Path == groups && r.Method == http.MethodPost:
+		var g unifi.FirewallGroup
+		json.NewDecoder(r.Body).Decode(&g)
+		g.ID = g.Name
+		fc.groups[g.ID] = &g
+		fc.updates++
+		data = []unifi.FirewallGroup{g}
+	case len(r.URL.Path) > len(groups) && r.URL.Path[:len(groups)] == groups && r.Method == http.MethodPut:
+		g := fc.groups[r.URL.Path[len(groups)+1:]]
+		if g == nil {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&struct {
+			Members *[]string `json:"group_members"`
+		}{&g.Members})
+		fc.updates++
+	case r.URL.Path == rules && r.Method == http.MethodGet:
+		data = fc.rules
+	case r.URL.Path == rules && r.Method == http.MethodPost:
+		var rule unifi.FirewallRule
+		json.NewDecoder(r.Body).Decode(&rule)
+		rule.ID = rule.Name
+		fc.rules = append(fc.rules, rule)
+		data = []unifi.FirewallRule{rule}
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+}
+
+// writes returns how many times a group was created or updated
+func (fc *fakeController) writes() int {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	return fc.updates
+}
+
+// members returns the members of the named group
+func (fc *fakeController) members(name string) []string {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	if g := fc.groups[name]; g != nil {
+		return slices.Clone(g.Members)
+	}
+	return nil
+}
+
+// newTestSyncer returns a syncer talking to a fake controller that holds the
+// group "uts-block-list" with the given members, or no group without them
+func newTestSyncer(t *testing.T, cfg *config.Config, members ...string) (*Syncer, *fakeController) {
+	t.Helper()
+	fc := &fakeController{groups: make(map[string]*unifi.FirewallGroup)}
+	if members != nil {
+		fc.groups["uts-block-list"] = &unifi.FirewallGroup{ID: "uts-block-list", Name: "uts-block-list", Type: "address-group", Members: members}
+	}
+	srv := httptest.NewServer(fc)
+	t.Cleanup(srv.Close)
+
+	cfg.UniFi.URL = srv.URL
+	cfg.UniFi.Site = "default"
+	client, err := unifi.NewClient(cfg.UniFi)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return New(cfg, client), fc
+}
+
+var testProfile = config.ProfileConfig{Name: "default", GroupName: "uts-block-list", Ruleset: "WAN_IN", RuleIndex: 2000}
+
+// testSources returns a single feed listing the given CIDRs
+func testSources(cidrs ...string) []normalizer.Source {
+	prefixes := make([]netip.Prefix, len(cidrs))
+	for i, c := range cidrs {
+		prefixes[i] = netip.MustParsePrefix(c)
+	}
+	return []normalizer.Source{{Name: "feed", Prefixes: prefixes}}
+}
+
+func TestSyncProfileMinAgeAfterRestart(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Sync.MinAge = time.Hour
+
+	// The group was filled before the restart, aggregated into a /31
+	s, fc := newTestSyncer(t, cfg, "192.0.2.0/31", "198.51.100.7")
+	sources := testSources("192.0.2.0/32", "192.0.2.1/32", "198.51.100.7/32", "203.0.113.9/32")
+
+	if err := s.syncProfile(context.Background(), testProfile, sources, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// Entries already blocked stay blocked and the new one waits for minAge,
+	// so the group is left as it is
+	want := []string{"192.0.2.0/31", "198.51.100.7"}
+	if got := fc.members("uts-block-list"); !slices.Equal(got, want) {
+		t.Errorf("group members = %v, want %v", got, want)
+	}
+	if got := fc.writes(); got != 0 {
+		t.Errorf("group written %d times, want 0", got)
+	}
+	if got := len(s.Published()[0].Entries); got != 2 {
+		t.Errorf("published %d entries, want 2", got)
+	}
+}
+
+func TestSyncProfileMinAgeNewGroup(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Sync.MinAge = time.Hour
+
+	s, fc := newTestSyncer(t, cfg)
+	if err := s.syncProfile(context.Background(), testProfile, testSources("192.0.2.1/32"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := fc.members("uts-block-list"); len(got) != 0 {
+		t.Errorf("group members = %v, want none before minAge", got)
+	}
+}
+
+func TestSyncProfileFreshStateMatchingGroup(t *testing.T) {
+	s, fc := newTestSyncer(t, &config.Config{}, "198.51.100.7", "192.0.2.0/24")
+	ctx := context.Background()
+
+	// The controller already holds the list, written differently
+	if err := s.syncProfile(ctx, testProfile, testSources("192.0.2.0/25", "192.0.2.128/25", "198.51.100.7/32"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := fc.writes(); got != 0 {
+		t.Errorf("group written %d times, want 0", got)
+	}
+	if s.profile(testProfile.Name).lastHash == "" {
+		t.Error("lastHash not set for a matching group")
+	}
+
+	// A real change is still pushed
+	if err := s.syncProfile(ctx, testProfile, testSources("192.0.2.0/24"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := fc.members("uts-block-list"), []string{"192.0.2.0/24"}; !slices.Equal(got, want) {
+		t.Errorf("group members = %v, want %v", got, want)
+	}
+}
+
+// staticManual is a ManualSource with fixed entries
+type staticManual []netip.Prefix
+
+func (m staticManual) Active() ([]netip.Prefix, error) { return m, nil }
+
+func TestSyncProfileManualPolicy(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Filter.Prefix.MinIPv4 = 16
+
+	// Stored before filter.prefix was raised, or before the check existed
+	s, fc := newTestSyncer(t, cfg)
+	s.SetManualSource(staticManual{
+		netip.MustParsePrefix("0.0.0.0/0"),
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("100.64.0.0/16"),
+		netip.MustParsePrefix("45.155.205.0/24"),
+	})
+	if err := s.syncProfile(context.Background(), testProfile, testSources("192.0.2.1/32"), nil); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"45.155.205.0/24", "192.0.2.1/32"}
+	if got := fc.members("uts-block-list"); !slices.Equal(got, want) {
+		t.Errorf("group members = %v, want %v", got, want)
+	}
+}
+
+// eventRecorder is a Notifier keeping the events it is sent
+type eventRecorder struct {
+	events []notify.Event
+}
+
+func (r *eventRecorder) Notify(ctx context.Context, event notify.Event) {
+	r.events = append(r.events, event)
+}
+
+func TestSyncProfileLargeChangeThreshold(t *testing.T) {
+	tests := []struct {
+		name        string
+		largeChange *int
+		want        int
+	}{
+		{"default of 1000", nil, 0},
+		{"zero is every push", new(int), 1},
+	}
+	for _, tt := range tests {
+		cfg := &config.Config{}
+		cfg.Notify.LargeChange = tt.largeChange
+		s, _ := newTestSyncer(t, cfg, "192.0.2.1")
+		events := &eventRecorder{}
+		s.SetNotifier(events)
+
+		if err := s.syncProfile(context.Background(), testProfile, testSources("192.0.2.2/32"), nil); err != nil {
+			t.Fatal(err)
+		}
+		if got := len(events.events); got != tt.want {
+			t.Errorf("%s: %d events, want %d", tt.name, got, tt.want)
+		}
+	}
+}
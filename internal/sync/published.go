@@ -0,0 +1,146 @@
+package sync
+
+import (
+	"net/netip"
+	"sort"
+	"time"
+
+	"github.com/0x4272616E646F6E/unifi-threat-sync/internal/config"
+	"github.com/0x4272616E646F6E/unifi-threat-sync/internal/normalizer"
+)
+
+// ManualSourceName is the source name recorded for manually blocked entries
+const ManualSourceName = "manual"
+
+// Published is the list last pushed to (or confirmed on) a profile's
+// firewall group, with the feeds that contributed each prefix
+type Published struct {
+	Profile   string           `json:"profile"`
+	Group     string           `json:"group"`
+	UpdatedAt time.Time        `json:"updatedAt"`
+	Entries   []PublishedEntry `json:"entries"`
+
+	index *normalizer.Trie[int] // Prefix of every entry to its position
+}
+
+// PublishedEntry is a published prefix with its provenance and the times it
+// was first and last seen in the feeds (zero for manual entries, pieces split
+// off by the allowlist and prefixes merged by aggregation)
+type PublishedEntry struct {
+	normalizer.Entry
+	FirstSeen time.Time `json:"firstSeen,omitzero"`
+	LastSeen  time.Time `json:"lastSeen,omitzero"`
+}
+
+// publish records the provenance of a profile's published list. Prefixes no
+// longer listed by any feed (held by the grace period) keep the sources they
+// had when last published.
+func (s *Syncer) publish(profile config.ProfileConfig, state *profileState, prefixes []netip.Prefix, index *normalizer.Index) {
+	s.publishedMu.RLock()
+	previous := make(map[string][]string)
+	if prev := s.published[profile.Name]; prev != nil {
+		for _, e := range prev.Entries {
+			previous[e.CIDR] = e.Sources
+		}
+	}
+	s.publishedMu.RUnlock()
+
+	entries := make([]PublishedEntry, len(prefixes))
+	trie := &normalizer.Trie[int]{}
+	for i, prefix := range prefixes {
+		trie.Insert(prefix, i)
+		key := prefix.String()
+		sources := index.Sources(prefix)
+		if len(sources) == 0 {
+			sources = previous[key]
+		}
+		entries[i] = PublishedEntry{Entry: normalizer.Entry{Prefix: prefix, CIDR: key, Sources: sources}}
+		if ps := state.prefixStates[prefix]; ps != nil {
+			entries[i].FirstSeen = ps.firstSeen
+			entries[i].LastSeen = ps.lastSeen
+		}
+	}
+
+	s.publishedMu.Lock()
+	s.published[profile.Name] = &Published{
+		Profile:   profile.Name,
+		Group:     profile.GroupName,
+		UpdatedAt: time.Now().UTC(),
+		Entries:   entries,
+		index:     trie,
+	}
+	s.publishedMu.Unlock()
+}
+
+// Published returns the last published list of every profile. The returned
+// values must not be modified.
+func (s *Syncer) Published() []Published {
+	s.publishedMu.RLock()
+	defer s.publishedMu.RUnlock()
+
+	result := make([]Published, 0, len(s.published))
+	for _, profile := range s.config.GetProfiles() {
+		if p := s.published[profile.Name]; p != nil {
+			result = append(result, *p)
+		}
+	}
+	return result
+}
+
+// LookupMatch is a published prefix covering a looked-up address
+type LookupMatch struct {
+	Profile string `json:"profile"`
+	Group   string `json:"group"`
+	PublishedEntry
+}
+
+// LookupResult explains why an address is (or is not) blocked
+type LookupResult struct {
+	IP        string        `json:"ip"`
+	Blocked   bool          `json:"blocked"`
+	Matches   []LookupMatch `json:"matches"`   // Longest prefix first
+	Allowlist []string      `json:"allowlist"` // Allowlist entries covering the address
+}
+
+// Lookup returns every published prefix covering addr, longest prefix first,
+// along with the allowlist entries that cover it
+func (s *Syncer) Lookup(addr netip.Addr) LookupResult {
+	result := LookupResult{
+		IP:        addr.String(),
+		Matches:   []LookupMatch{},
+		Allowlist: []string{},
+	}
+
+	s.publishedMu.RLock()
+	defer s.publishedMu.RUnlock()
+
+	host := netip.PrefixFrom(addr, addr.BitLen())
+	for _, profile := range s.config.GetProfiles() {
+		p := s.published[profile.Name]
+		if p == nil {
+			continue
+		}
+		for _, i := range p.index.Covering(host) {
+			result.Matches = append(result.Matches, LookupMatch{Profile: p.Profile, Group: p.Group, PublishedEntry: p.Entries[i]})
+		}
+	}
+	if s.allowed != nil {
+		for a := range s.allowed.Covering(host) {
+			result.Allowlist = append(result.Allowlist, a.String())
+		}
+	}
+
+	sort.SliceStable(result.Matches, func(i, j int) bool {
+		return result.Matches[i].Prefix.Bits() > result.Matches[j].Prefix.Bits()
+	})
+	result.Blocked = len(result.Matches) > 0
+	return result
+}
+
+// setAllowed records the allowlist used by the current run for lookups
+func (s *Syncer) setAllowed(allowed []netip.Prefix) {
+	trie := normalizer.NewTrie[struct{}](allowed)
+	s.publishedMu.Lock()
+	s.allowed = trie
+	s.publishedMu.Unlock()
+}
@@ -0,0 +1,170 @@
+package sync
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+)
+
+// Run statuses reported in RunInfo
+const (
+	RunPending   = "pending"
+	RunRunning   = "running"
+	RunSucceeded = "succeeded"
+	RunFailed    = "failed"
+)
+
+// maxRuns is how many runs are kept for RunInfo lookups
+const maxRuns = 100
+
+// RunInfo describes a sync run and, once finished, its result
+type RunInfo struct {
+	ID          string     `json:"id"`
+	Trigger     string     `json:"trigger"` // schedule, api, signal, ...
+	Status      string     `json:"status"`
+	RequestedAt time.Time  `json:"requestedAt"`
+	StartedAt   *time.Time `json:"startedAt,omitempty"`
+	FinishedAt  *time.Time `json:"finishedAt,omitempty"`
+	Changed     bool       `json:"changed"`               // At least one group was created or updated
+	Held        bool       `json:"held,omitempty"`        // Changes were not pushed because a blackout window was active
+	FailedFeeds []string   `json:"failedFeeds,omitempty"` // Feeds whose fetch failed; data from an earlier fetch was used if there was any
+	Error       string     `json:"error,omitempty"`
+}
+
+// Request records a run to be started by the next call to Run and returns
+// it. Requests made while a run is already pending are coalesced into that
+// run, so it is returned instead.
+func (s *Syncer) Request(trigger string) RunInfo {
+	s.runsMu.Lock()
+	defer s.runsMu.Unlock()
+
+	if s.pending != nil {
+		return *s.pending
+	}
+	s.pending = s.newRun(trigger)
+	return *s.pending
+}
+
+// RunInfo returns the run with the given ID
+func (s *Syncer) RunInfo(id string) (RunInfo, bool) {
+	s.runsMu.Lock()
+	defer s.runsMu.Unlock()
+
+	for _, run := range s.runs {
+		if run.ID == id {
+			return *run, true
+		}
+	}
+	return RunInfo{}, false
+}
+
+// Runs returns the most recent runs, newest first
+func (s *Syncer) Runs() []RunInfo {
+	s.runsMu.Lock()
+	defer s.runsMu.Unlock()
+
+	result := make([]RunInfo, len(s.runs))
+	for i, run := range s.runs {
+		result[len(s.runs)-1-i] = *run
+	}
+	return result
+}
+
+// newRun creates a pending run and adds it to the history. runsMu must be
+// held.
+func (s *Syncer) newRun(trigger string) *RunInfo {
+	var id [8]byte
+	rand.Read(id[:])
+
+	run := &RunInfo{
+		ID:          hex.EncodeToString(id[:]),
+		Trigger:     trigger,
+		Status:      RunPending,
+		RequestedAt: time.Now(),
+	}
+	s.runs = append(s.runs, run)
+	if len(s.runs) > maxRuns {
+		s.runs = s.runs[len(s.runs)-maxRuns:]
+	}
+	return run
+}
+
+// startRun marks the pending run, or a new scheduled one, as running
+func (s *Syncer) startRun() *RunInfo {
+	s.runsMu.Lock()
+	defer s.runsMu.Unlock()
+
+	run := s.pending
+	s.pending = nil
+	if run == nil {
+		run = s.newRun("schedule")
+	}
+	now := time.Now()
+	run.Status = RunRunning
+	run.StartedAt = &now
+	s.current = run
+	return run
+}
+
+// DiscardPending fails the pending run, if any, with err. It is used when
+// the run can no longer be started, such as after losing leadership.
+func (s *Syncer) DiscardPending(err error) {
+	s.runsMu.Lock()
+	defer s.runsMu.Unlock()
+
+	run := s.pending
+	if run == nil {
+		return
+	}
+	s.pending = nil
+	now := time.Now()
+	run.FinishedAt = &now
+	run.Status = RunFailed
+	run.Error = err.Error()
+}
+
+// noteFeedFailure records a failed feed fetch in the current run
+func (s *Syncer) noteFeedFailure(name string) {
+	s.runsMu.Lock()
+	defer s.runsMu.Unlock()
+
+	if s.current != nil {
+		s.current.FailedFeeds = append(s.current.FailedFeeds, name)
+	}
+}
+
+// noteChange records that the current run changed a group on the controller
+func (s *Syncer) noteChange() {
+	s.runsMu.Lock()
+	defer s.runsMu.Unlock()
+
+	if s.current != nil {
+		s.current.Changed = true
+	}
+}
+
+// noteHeld records that the current run held back changes during a blackout
+// window
+func (s *Syncer) noteHeld() {
+	s.runsMu.Lock()
+	defer s.runsMu.Unlock()
+
+	if s.current != nil {
+		s.current.Held = true
+	}
+}
+
+// finishRun records the result of a run
+func (s *Syncer) finishRun(run *RunInfo, err error) {
+	s.runsMu.Lock()
+	defer s.runsMu.Unlock()
+
+	s.current = nil
+	now := time.Now()
+	run.FinishedAt = &now
+	run.Status = RunSucceeded
+	if err != nil {
+		run.Status = RunFailed
+		run.Error = err.Error()
+	}
+}
@@ -0,0 +1,73 @@
+package sync
+
+import (
+	"fmt"
+	"net/netip"
+	"time"
+
+	"github.com/0x4272616E646F6E/unifi-threat-sync/internal/normalizer"
+)
+
+// prefixState tracks when a prefix was seen in the feeds and whether it is
+// currently published to the controller
+type prefixState struct {
+	prefix    netip.Prefix
+	firstSeen time.Time
+	lastSeen  time.Time
+	published bool
+}
+
+// stabilize damps membership churn from volatile feeds. A prefix is only
+// published once it has been listed for sync.minAge and stays published for
+// sync.gracePeriod after it disappears from all feeds. A prefix first seen
+// while the state is fresh counts as old enough when the group's members
+// already cover it, so entries blocked before a restart stay blocked.
+func (s *Syncer) stabilize(ps *profileState, current []netip.Prefix, now time.Time) []netip.Prefix {
+	minAge := s.config.Sync.MinAge
+	grace := s.config.Sync.GracePeriod
+
+	for _, prefix := range current {
+		state, ok := ps.prefixStates[prefix]
+		if !ok {
+			state = &prefixState{prefix: prefix, firstSeen: now}
+			if ps.seed != nil {
+				if _, _, covered := ps.seed.LongestMatch(prefix); covered {
+					state.firstSeen = now.Add(-minAge)
+				}
+			}
+			ps.prefixStates[prefix] = state
+		}
+		state.lastSeen = now
+	}
+	ps.seed = nil
+
+	result := make([]netip.Prefix, 0, len(current))
+	held, pending := 0, 0
+	for key, state := range ps.prefixStates {
+		present := state.lastSeen.Equal(now)
+
+		switch {
+		case present && now.Sub(state.firstSeen) >= minAge:
+			state.published = true
+		case !present && state.published && now.Sub(state.lastSeen) <= grace:
+			held++
+		case present:
+			pending++
+			state.published = false
+		default:
+			// Gone from all feeds and outside the grace period
+			delete(ps.prefixStates, key)
+			continue
+		}
+
+		if state.published {
+			result = append(result, state.prefix)
+		}
+	}
+
+	if held > 0 || pending > 0 {
+		fmt.Printf("Stability: %d entries held by grace period, %d waiting for minimum age\n", held, pending)
+	}
+
+	return normalizer.Normalize(result)
+}
@@ -4,36 +4,95 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
-	"net"
-	"sort"
+	"net/netip"
+	"reflect"
+	gosync "sync"
+	"time"
 
+	"github.com/0x4272616E646F6E/unifi-threat-sync/internal/audit"
 	"github.com/0x4272616E646F6E/unifi-threat-sync/internal/config"
 	"github.com/0x4272616E646F6E/unifi-threat-sync/internal/normalizer"
-	"github.com/0x4272616E646F6E/unifi-threat-sync/internal/parser"
+	"github.com/0x4272616E646F6E/unifi-threat-sync/internal/notify"
 	"github.com/0x4272616E646F6E/unifi-threat-sync/internal/unifi"
 )
 
+// ErrSafetyLimit is returned when a push is aborted by a safety limit
+var ErrSafetyLimit = errors.New("safety limit exceeded")
+
+// ErrController is matched by errors.Is when the UniFi controller failed or
+// rejected a change
+var ErrController = errors.New("controller error")
+
+// controllerError marks an error returned by the UniFi client
+type controllerError struct {
+	err error
+}
+
+func (e controllerError) Error() string        { return e.err.Error() }
+func (e controllerError) Unwrap() error        { return e.err }
+func (e controllerError) Is(target error) bool { return target == ErrController }
+
 // HealthRecorder is an interface for recording health metrics
 type HealthRecorder interface {
 	RecordSync()
 	RecordError()
+	RecordFeedStatus(name string, count, filtered, rejected, split int, lastFetch, nextRefresh time.Time, err error)
+	RecordVerify(group string, missing, unexpected int)
+	RecordSummary(group string, merges int, extraAddresses float64)
+}
+
+// PushGate decides whether changes may currently be pushed to the controller
+type PushGate interface {
+	Blocked() bool
+}
+
+// Notifier delivers sync events to external services
+type Notifier interface {
+	Notify(ctx context.Context, event notify.Event)
+}
+
+// AuditLog records controller mutations
+type AuditLog interface {
+	Write(rec audit.Record) error
+}
+
+// ManualSource provides manually blocked entries to merge into every profile
+type ManualSource interface {
+	Active() ([]netip.Prefix, error)
 }
 
 // Syncer handles the synchronization process
 type Syncer struct {
 	config         *config.Config
 	unifiClient    *unifi.Client
-	lastHash       string
 	healthRecorder HealthRecorder
+	pushGate       PushGate
+	manualSource   ManualSource
+	notifier       Notifier
+	auditLog       AuditLog
+	failing        bool
+	feedStates     map[string]*feedState
+	profileStates  map[string]*profileState
+	publishedMu    gosync.RWMutex
+	published      map[string]*Published
+	allowed        *normalizer.Trie[struct{}]
+	runMu          gosync.Mutex
+	runsMu         gosync.Mutex
+	runs           []*RunInfo
+	pending        *RunInfo
+	current        *RunInfo
 }
 
 // New creates a new Syncer
 func New(cfg *config.Config, unifiClient *unifi.Client) *Syncer {
 	return &Syncer{
-		config:      cfg,
-		unifiClient: unifiClient,
-		lastHash:    "",
+		config:        cfg,
+		unifiClient:   unifiClient,
+		feedStates:    make(map[string]*feedState),
+		profileStates: make(map[string]*profileState),
+		published:     make(map[string]*Published),
 	}
 }
 
@@ -42,109 +101,164 @@ func (s *Syncer) SetHealthRecorder(hr HealthRecorder) {
 	s.healthRecorder = hr
 }
 
-// Run performs a full synchronization cycle
-func (s *Syncer) Run(ctx context.Context) error {
-	fmt.Println("Starting sync cycle...")
+// SetPushGate sets the gate consulted before pushing changes
+func (s *Syncer) SetPushGate(g PushGate) {
+	s.pushGate = g
+}
 
-	// Fetch and parse all enabled feeds
-	allNetworks, err := s.fetchAllFeeds(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to fetch feeds: %w", err)
-	}
+// SetManualSource sets the source of manually blocked entries
+func (s *Syncer) SetManualSource(m ManualSource) {
+	s.manualSource = m
+}
 
-	fmt.Printf("Fetched %d total IPs/CIDRs from feeds\n", len(allNetworks))
+// SetNotifier sets the notifier for sync events
+func (s *Syncer) SetNotifier(n Notifier) {
+	s.notifier = n
+}
 
-	// Normalize (deduplicate and sort)
-	normalized := normalizer.Normalize(allNetworks)
-	fmt.Printf("After deduplication: %d unique IPs/CIDRs\n", len(normalized))
+// SetAuditLog sets the log every controller mutation is recorded in
+func (s *Syncer) SetAuditLog(l AuditLog) {
+	s.auditLog = l
+}
 
-	// Calculate hash of normalized list
-	currentHash := s.calculateHash(normalized)
+// SetConfig replaces the configuration used by subsequent runs, waiting for a
+// run in progress to finish. Cached results of feeds whose settings
+// changed are dropped so they are refetched, and profiles whose settings
+// changed start over so their group is pushed on the next run.
+func (s *Syncer) SetConfig(cfg *config.Config) {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
 
-	// Check if update is needed
-	if currentHash == s.lastHash {
-		fmt.Println("No changes detected, skipping update")
-		return nil
+	feeds := make(map[string]config.FeedConfig)
+	for _, f := range cfg.Feeds.GetEnabled() {
+		feeds[f.Name] = f
+	}
+	for _, f := range cfg.Allowlist.Feeds {
+		feeds[f.Name] = f
+	}
+	old := make(map[string]config.FeedConfig)
+	for _, f := range s.config.Feeds.GetEnabled() {
+		old[f.Name] = f
+	}
+	for _, f := range s.config.Allowlist.Feeds {
+		old[f.Name] = f
+	}
+	filterChanged := cfg.Filter.FilterReserved() != s.config.Filter.FilterReserved() ||
+		cfg.Filter.Prefix != s.config.Filter.Prefix
+	for name := range s.feedStates {
+		if f, ok := feeds[name]; !ok || filterChanged || !reflect.DeepEqual(f, old[name]) {
+			delete(s.feedStates, name)
+		}
 	}
 
-	fmt.Println("Changes detected, updating UniFi...")
+	profiles := make(map[string]config.ProfileConfig)
+	for _, p := range cfg.GetProfiles() {
+		profiles[p.Name] = p
+	}
 
-	// Convert to strings for UniFi API
-	members := normalizer.ToStrings(normalized)
+	s.publishedMu.Lock()
+	defer s.publishedMu.Unlock()
 
-	// Get or create firewall group
-	group, err := s.unifiClient.GetFirewallGroup(ctx, s.config.UniFi.GroupName)
-	if err != nil {
-		fmt.Printf("Group '%s' not found, creating...\n", s.config.UniFi.GroupName)
-		group, err = s.unifiClient.CreateFirewallGroup(ctx, s.config.UniFi.GroupName, members)
-		if err != nil {
-			return fmt.Errorf("failed to create firewall group: %w", err)
-		}
-		fmt.Printf("Created firewall group '%s'\n", s.config.UniFi.GroupName)
-	} else {
-		// Update existing group
-		fmt.Printf("Updating firewall group '%s'...\n", s.config.UniFi.GroupName)
-		if err := s.unifiClient.UpdateFirewallGroup(ctx, group.ID, members); err != nil {
-			return fmt.Errorf("failed to update firewall group: %w", err)
+	for _, p := range s.config.GetProfiles() {
+		if next, ok := profiles[p.Name]; !ok || !reflect.DeepEqual(next, p) {
+			delete(s.profileStates, p.Name)
+			delete(s.published, p.Name)
 		}
-		fmt.Printf("Updated firewall group '%s'\n", s.config.UniFi.GroupName)
 	}
+	s.config = cfg
+}
 
-	// Update last hash
-	s.lastHash = currentHash
+// Run performs a full synchronization cycle. Feeds are fetched once and every
+// output profile is built and pushed independently from the results. Calls
+// are serialized, so runs never overlap; a run recorded by Request is picked
+// up by the next call.
+func (s *Syncer) Run(ctx context.Context) error {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
 
-	// Record successful sync
-	if s.healthRecorder != nil {
-		s.healthRecorder.RecordSync()
-	}
+	info := s.startRun()
+	fmt.Printf("Starting sync cycle %s (%s)...\n", info.ID, info.Trigger)
 
-	fmt.Println("Sync completed successfully")
-	return nil
+	err := s.run(ctx)
+	s.notifyOutcome(ctx, err)
+	s.finishRun(info, err)
+	return err
 }
 
-// fetchAllFeeds fetches and parses all enabled feeds
-func (s *Syncer) fetchAllFeeds(ctx context.Context) ([]net.IPNet, error) {
-	var allNetworks []net.IPNet
-
-	enabledFeeds := s.config.Feeds.GetEnabled()
+// run does the work of Run
+func (s *Syncer) run(ctx context.Context) error {
+	// Fetch and parse all enabled feeds
+	sources, err := s.fetchAllFeeds(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch feeds: %w", err)
+	}
 
-	for _, feedConfig := range enabledFeeds {
-		fmt.Printf("Fetching feed: %s (%s)\n", feedConfig.Name, feedConfig.Parser)
+	fmt.Printf("Fetched %d total IPs/CIDRs from feeds\n", len(normalizer.Flatten(sources)))
 
-		// Get parser
-		p, err := parser.Get(feedConfig.Parser)
-		if err != nil {
-			fmt.Printf("  Warning: %v, skipping\n", err)
-			continue
-		}
+	// Load allowlisted ranges shared by all profiles
+	allowed, err := s.loadAllowlist(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load allowlist: %w", err)
+	}
+	s.setAllowed(allowed)
 
-		// Parse feed
-		networks, err := p.Parse(ctx, feedConfig)
-		if err != nil {
-			fmt.Printf("  Warning: failed to parse feed: %v, skipping\n", err)
-			continue
+	var errs []error
+	for _, profile := range s.config.GetProfiles() {
+		if err := s.syncProfile(ctx, profile, sources, allowed); err != nil {
+			errs = append(errs, fmt.Errorf("profile %s: %w", profile.Name, err))
 		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
 
-		fmt.Printf("  Found %d IPs/CIDRs\n", len(networks))
-		allNetworks = append(allNetworks, networks...)
+	// Record successful sync
+	if s.healthRecorder != nil {
+		s.healthRecorder.RecordSync()
 	}
 
-	return allNetworks, nil
+	fmt.Println("Sync completed successfully")
+	return nil
 }
 
-// calculateHash calculates a SHA256 hash of the normalized network list
-func (s *Syncer) calculateHash(networks []net.IPNet) string {
-	// Convert to sorted string list
-	strs := normalizer.ToStrings(networks)
-	sort.Strings(strs)
+// notifyOutcome sends failure, safety and recovery notifications for a run
+func (s *Syncer) notifyOutcome(ctx context.Context, err error) {
+	wasFailing := s.failing
+	s.failing = err != nil
+	if s.notifier == nil {
+		return
+	}
 
-	// Create concatenated string
-	combined := ""
-	for _, str := range strs {
-		combined += str + "\n"
+	switch {
+	case errors.Is(err, ErrSafetyLimit):
+		s.notifier.Notify(ctx, notify.Event{
+			Type:    notify.EventSafety,
+			Message: "Sync aborted by safety limit, controller not updated",
+			Error:   err.Error(),
+		})
+	case err != nil:
+		s.notifier.Notify(ctx, notify.Event{
+			Type:    notify.EventFailure,
+			Message: "Sync failed",
+			Error:   err.Error(),
+		})
+	case wasFailing:
+		s.notifier.Notify(ctx, notify.Event{
+			Type:    notify.EventRecovery,
+			Message: "Sync recovered after previous failure",
+		})
 	}
+}
 
-	// Calculate hash
-	hash := sha256.Sum256([]byte(combined))
-	return hex.EncodeToString(hash[:])
+// calculateHash calculates a SHA256 hash of the normalized prefix list,
+// which is sorted as Aggregate and Summarize return it. Prefixes are written
+// to the hash one at a time instead of being joined into one string.
+func (s *Syncer) calculateHash(prefixes []netip.Prefix) string {
+	h := sha256.New()
+	buf := make([]byte, 0, 64)
+	for _, p := range prefixes {
+		buf = append(p.AppendTo(buf[:0]), '\n')
+		h.Write(buf)
+	}
+	return hex.EncodeToString(h.Sum(nil))
 }
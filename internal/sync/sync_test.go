@@ -1 +1,62 @@
 package sync
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/netip"
+	"sort"
+	"testing"
+
+	"github.com/0x4272616E646F6E/unifi-threat-sync/internal/normalizer"
+)
+
+// hashBenchPrefixes returns n distinct sorted /24 prefixes
+func hashBenchPrefixes(n int) []netip.Prefix {
+	prefixes := make([]netip.Prefix, n)
+	for i := range prefixes {
+		addr := netip.AddrFrom4([4]byte{byte(i >> 16), byte(i >> 8), byte(i), 0})
+		prefixes[i] = netip.PrefixFrom(addr, 24)
+	}
+	return prefixes
+}
+
+// calculateHashByString is the previous calculateHash, which joined the
+// sorted list into one string, kept as a baseline for BenchmarkCalculateHash
+func calculateHashByString(prefixes []netip.Prefix) string {
+	strs := normalizer.ToStrings(prefixes)
+	sort.Strings(strs)
+
+	combined := ""
+	for _, str := range strs {
+		combined += str + "\n"
+	}
+
+	hash := sha256.Sum256([]byte(combined))
+	return hex.EncodeToString(hash[:])
+}
+
+func BenchmarkCalculateHash(b *testing.B) {
+	s := &Syncer{}
+	for _, n := range []int{1_000, 10_000, 1_000_000} {
+		prefixes := hashBenchPrefixes(n)
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			for b.Loop() {
+				s.calculateHash(prefixes)
+			}
+		})
+	}
+}
+
+// BenchmarkCalculateHashByString stops at 10,000 prefixes; the repeated
+// string concatenation is quadratic, so a million prefixes would run for hours
+func BenchmarkCalculateHashByString(b *testing.B) {
+	for _, n := range []int{1_000, 10_000} {
+		prefixes := hashBenchPrefixes(n)
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			for b.Loop() {
+				calculateHashByString(prefixes)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/0x4272616E646F6E/unifi-threat-sync/internal/config"
+	"github.com/0x4272616E646F6E/unifi-threat-sync/internal/indicator"
+	"github.com/0x4272616E646F6E/unifi-threat-sync/internal/normalizer"
+)
+
+// maxExamples is how many mismatched members are logged per direction
+const maxExamples = 5
+
+// verifyGroup reads the profile's group back after a write and checks that
+// the controller stored exactly the members sent. Depending on
+// sync.verify.onMismatch a mismatch is rewritten and checked again, fails the
+// sync or is only logged. Rewrites are audited like any other push.
+func (s *Syncer) verifyGroup(ctx context.Context, profile config.ProfileConfig, members []string, sources []normalizer.Source) error {
+	cfg := s.config.Sync.Verify
+	if !cfg.IsEnabled() {
+		return nil
+	}
+
+	retries := 0
+	if cfg.OnMismatch == "retry" {
+		retries = cfg.RetryCount()
+	}
+
+	want := canonicalMembers(members)
+	for attempt := 0; ; attempt++ {
+		group, err := s.unifiClient.GetFirewallGroup(ctx, profile.GroupName)
+		if err != nil {
+			return fmt.Errorf("failed to verify firewall group: %w", controllerError{err})
+		}
+
+		missing, unexpected := Diff(canonicalMembers(group.Members), want)
+		if s.healthRecorder != nil {
+			s.healthRecorder.RecordVerify(profile.GroupName, len(missing), len(unexpected))
+		}
+		if len(missing) == 0 && len(unexpected) == 0 {
+			fmt.Printf("Verified group '%s' (%d members)\n", profile.GroupName, len(group.Members))
+			return nil
+		}
+
+		fmt.Printf("Warning: group '%s' does not match what was sent: %d missing (e.g. %s), %d unexpected (e.g. %s)\n",
+			profile.GroupName, len(missing), examples(missing), len(unexpected), examples(unexpected))
+
+		if attempt >= retries {
+			if cfg.OnMismatch == "warn" {
+				return nil
+			}
+			return fmt.Errorf("failed to verify firewall group: %w", controllerError{fmt.Errorf(
+				"group '%s' has %d missing and %d unexpected members after %d retries",
+				profile.GroupName, len(missing), len(unexpected), attempt)})
+		}
+
+		fmt.Printf("Rewriting group '%s' (retry %d of %d)...\n", profile.GroupName, attempt+1, retries)
+		err = s.unifiClient.UpdateFirewallGroup(ctx, group.ID, members)
+		s.recordChange(ctx, profile, "rewrite", members, group.Members, sources, err)
+		if err != nil {
+			return fmt.Errorf("failed to update firewall group: %w", controllerError{err})
+		}
+	}
+}
+
+// canonicalMembers rewrites group members in the form ToStrings produces, so
+// a controller returning "192.0.2.1" for "192.0.2.1/32" is not a mismatch.
+// Members that don't parse are kept as they are.
+func canonicalMembers(members []string) []string {
+	result := make([]string, len(members))
+	for i, m := range members {
+		result[i] = m
+		if ind, err := indicator.Parse(m); err == nil {
+			result[i] = ind.Prefix.String()
+		}
+	}
+	return result
+}
+
+// examples formats the first few members of a list for logging
+func examples(members []string) string {
+	if len(members) == 0 {
+		return "none"
+	}
+	if len(members) > maxExamples {
+		return strings.Join(members[:maxExamples], ", ") + ", ..."
+	}
+	return strings.Join(members, ", ")
+}
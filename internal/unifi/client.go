@@ -3,6 +3,7 @@ package unifi
 import (
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/cookiejar"
@@ -11,6 +12,9 @@ import (
 	"github.com/0x4272616E646F6E/unifi-threat-sync/internal/config"
 )
 
+// ErrNotFound is returned when a named group or rule does not exist
+var ErrNotFound = errors.New("not found")
+
 // Client represents a UniFi controller client
 type Client struct {
 	config     config.UniFiConfig
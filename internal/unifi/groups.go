@@ -56,7 +56,7 @@ func (c *Client) GetFirewallGroup(ctx context.Context, name string) (*FirewallGr
 		}
 	}
 
-	return nil, fmt.Errorf("group not found: %s", name)
+	return nil, fmt.Errorf("group %w: %s", ErrNotFound, name)
 }
 
 // CreateFirewallGroup creates a new firewall group
@@ -1 +1,186 @@
 package unifi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+)
+
+// FirewallRule represents a UniFi firewall rule
+type FirewallRule struct {
+	ID                  string   `json:"_id,omitempty"`
+	Name                string   `json:"name"`
+	Ruleset             string   `json:"ruleset"`
+	RuleIndex           int      `json:"rule_index"`
+	Action              string   `json:"action"`
+	Enabled             bool     `json:"enabled"`
+	Protocol            string   `json:"protocol"`
+	Logging             bool     `json:"logging"`
+	SrcFirewallGroupIDs []string `json:"src_firewallgroup_ids"`
+	DstFirewallGroupIDs []string `json:"dst_firewallgroup_ids"`
+	SrcNetworkConfType  string   `json:"src_networkconf_type"`
+	DstNetworkConfType  string   `json:"dst_networkconf_type"`
+}
+
+// NewDropRule returns a rule dropping traffic that matches the group. Inbound
+// rulesets (WAN_IN, WAN_LOCAL) match the group as the source; all others
+// match it as the destination.
+func NewDropRule(name, ruleset string, index int, groupID string) FirewallRule {
+	rule := FirewallRule{
+		Name:                name,
+		Ruleset:             ruleset,
+		RuleIndex:           index,
+		Action:              "drop",
+		Enabled:             true,
+		Protocol:            "all",
+		SrcFirewallGroupIDs: []string{},
+		DstFirewallGroupIDs: []string{},
+		SrcNetworkConfType:  "NETv4",
+		DstNetworkConfType:  "NETv4",
+	}
+	if ruleset == "WAN_IN" || ruleset == "WAN_LOCAL" {
+		rule.SrcFirewallGroupIDs = []string{groupID}
+	} else {
+		rule.DstFirewallGroupIDs = []string{groupID}
+	}
+	return rule
+}
+
+// Matches reports whether the rule has the ruleset, index, action and group
+// bindings of want
+func (r FirewallRule) Matches(want FirewallRule) bool {
+	return r.Ruleset == want.Ruleset &&
+		r.RuleIndex == want.RuleIndex &&
+		r.Action == want.Action &&
+		r.Enabled == want.Enabled &&
+		slices.Equal(r.SrcFirewallGroupIDs, want.SrcFirewallGroupIDs) &&
+		slices.Equal(r.DstFirewallGroupIDs, want.DstFirewallGroupIDs)
+}
+
+// GetFirewallRule retrieves a firewall rule by name
+func (c *Client) GetFirewallRule(ctx context.Context, name string) (*FirewallRule, error) {
+	if err := c.ensureLoggedIn(ctx); err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s/proxy/network/api/s/%s/rest/firewallrule", c.baseURL, c.config.Site)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result struct {
+		Data []FirewallRule `json:"data"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	// Find rule by name
+	for _, rule := range result.Data {
+		if rule.Name == name {
+			return &rule, nil
+		}
+	}
+
+	return nil, fmt.Errorf("rule %w: %s", ErrNotFound, name)
+}
+
+// CreateFirewallRule creates a new firewall rule
+func (c *Client) CreateFirewallRule(ctx context.Context, rule FirewallRule) (*FirewallRule, error) {
+	if err := c.ensureLoggedIn(ctx); err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s/proxy/network/api/s/%s/rest/firewallrule", c.baseURL, c.config.Site)
+
+	rule.ID = ""
+	body, err := json.Marshal(rule)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal rule: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result struct {
+		Data []FirewallRule `json:"data"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	if len(result.Data) == 0 {
+		return nil, fmt.Errorf("no rule returned in response")
+	}
+
+	return &result.Data[0], nil
+}
+
+// UpdateFirewallRule replaces an existing firewall rule
+func (c *Client) UpdateFirewallRule(ctx context.Context, ruleID string, rule FirewallRule) error {
+	if err := c.ensureLoggedIn(ctx); err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("%s/proxy/network/api/s/%s/rest/firewallrule/%s", c.baseURL, c.config.Site, ruleID)
+
+	rule.ID = ruleID
+	body, err := json.Marshal(rule)
+	if err != nil {
+		return fmt.Errorf("failed to marshal rule: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "PUT", url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(body))
+	}
+
+	return nil
+}